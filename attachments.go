@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"mime"
 
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
@@ -14,16 +16,11 @@ func (conn *Connection) pullAttachments(m events.Message) (attachments []string,
 		if err != nil {
 			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
 		}
-		if len(raw) > 0 {
-			fileName := conn.hashFile(raw)
-			exts, _ := mime.ExtensionsByType(att.GetMimetype())
-			if len(exts) > 0 {
-				fileName += exts[0]
-			}
-			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
-			if err := conn.writeFileIfNotExists(path, raw); err != nil {
-				return attachments, caption, fmt.Errorf("failed to write attachment: %w", err)
-			}
+		fileName, err := conn.saveAttachment(raw, att.GetMimetype(), "")
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
 			attachments = append(attachments, fileName)
 		}
 	}
@@ -32,18 +29,11 @@ func (conn *Connection) pullAttachments(m events.Message) (attachments []string,
 		if err != nil {
 			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
 		}
-		if len(raw) > 0 {
-			fileName := conn.hashFile(raw)
-			exts, _ := mime.ExtensionsByType(att.GetMimetype())
-			if len(exts) > 0 {
-				fileName += exts[0]
-			} else {
-				fileName += ".ogg"
-			}
-			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
-			if err := conn.writeFileIfNotExists(path, raw); err != nil {
-				return attachments, caption, fmt.Errorf("failed to write attachment: %w", err)
-			}
+		fileName, err := conn.saveAttachment(raw, att.GetMimetype(), ".ogg")
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
 			attachments = append(attachments, fileName)
 		}
 	}
@@ -53,18 +43,24 @@ func (conn *Connection) pullAttachments(m events.Message) (attachments []string,
 		if err != nil {
 			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
 		}
-		if len(raw) > 0 {
-			fileName := conn.hashFile(raw)
-			exts, _ := mime.ExtensionsByType(att.GetMimetype())
-			if len(exts) > 0 {
-				fileName += exts[0]
-			} else {
-				fileName += ".mp4"
-			}
-			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
-			if err := conn.writeFileIfNotExists(path, raw); err != nil {
-				return attachments, caption, fmt.Errorf("failed to write attachment: %w", err)
-			}
+		fileName, err := conn.saveAttachment(raw, att.GetMimetype(), ".mp4")
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
+			attachments = append(attachments, fileName)
+		}
+	}
+	if att := m.Message.GetPtvMessage(); att != nil {
+		raw, err := conn.client.Download(conn.ctx, att)
+		if err != nil {
+			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
+		}
+		fileName, err := conn.saveAttachment(raw, att.GetMimetype(), ".mp4")
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
 			attachments = append(attachments, fileName)
 		}
 	}
@@ -74,16 +70,11 @@ func (conn *Connection) pullAttachments(m events.Message) (attachments []string,
 		if err != nil {
 			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
 		}
-		if len(raw) > 0 {
-			fileName := conn.hashFile(raw)
-			exts, _ := mime.ExtensionsByType(att.GetMimetype())
-			if len(exts) > 0 {
-				fileName += exts[0]
-			}
-			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
-			if err := conn.writeFileIfNotExists(path, raw); err != nil {
-				return attachments, caption, fmt.Errorf("failed to write attachment: %w", err)
-			}
+		fileName, err := conn.saveAttachment(raw, att.GetMimetype(), "")
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
 			attachments = append(attachments, fileName)
 		}
 	}
@@ -92,18 +83,113 @@ func (conn *Connection) pullAttachments(m events.Message) (attachments []string,
 		if err != nil {
 			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
 		}
-		if len(raw) > 0 {
-			fileName := conn.hashFile(raw)
-			exts, _ := mime.ExtensionsByType(att.GetMimetype())
-			if len(exts) > 0 {
-				fileName += exts[0]
+		fallbackExt := ".webp"
+		if att.GetIsLottie() || m.IsLottieSticker {
+			fallbackExt = ".was"
+		}
+		fileName, err := conn.saveAttachment(raw, att.GetMimetype(), fallbackExt)
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
+			attachments = append(attachments, fileName)
+		}
+	}
+	if att := m.Message.GetStickerPackMessage(); att != nil {
+		raw, err := conn.client.Download(conn.ctx, att)
+		if err != nil {
+			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
+		}
+		fileName, err := conn.saveAttachment(raw, "", ".zip")
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
+			attachments = append(attachments, fileName)
+		}
+	}
+	if x := m.Message.GetProductMessage(); x != nil {
+		for _, att := range []*waE2E.ImageMessage{x.GetProduct().GetProductImage(), x.GetCatalog().GetCatalogImage()} {
+			if att == nil {
+				continue
 			}
-			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
-			if err := conn.writeFileIfNotExists(path, raw); err != nil {
-				return attachments, caption, fmt.Errorf("failed to write attachment: %w", err)
+			raw, err := conn.client.Download(conn.ctx, att)
+			if err != nil {
+				return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
 			}
+			fileName, err := conn.saveAttachment(raw, att.GetMimetype(), ".jpeg")
+			if err != nil {
+				return attachments, caption, err
+			}
+			if fileName != "" {
+				attachments = append(attachments, fileName)
+			}
+		}
+	}
+	if x := m.Message.GetInvoiceMessage(); x != nil && x.GetAttachmentDirectPath() != "" {
+		mediaType, fallbackExt := whatsmeow.MediaImage, ".jpeg"
+		if x.GetAttachmentType() == waE2E.InvoiceMessage_PDF {
+			mediaType, fallbackExt = whatsmeow.MediaDocument, ".pdf"
+		}
+		raw, err := conn.client.DownloadMediaWithPath(conn.ctx, x.GetAttachmentDirectPath(), x.AttachmentFileEncSHA256, x.AttachmentFileSHA256, x.AttachmentMediaKey, -1, mediaType, "")
+		if err != nil {
+			return attachments, caption, fmt.Errorf("failed to download attachment: %w", err)
+		}
+		fileName, err := conn.saveAttachment(raw, x.GetAttachmentMimetype(), fallbackExt)
+		if err != nil {
+			return attachments, caption, err
+		}
+		if fileName != "" {
 			attachments = append(attachments, fileName)
 		}
 	}
 	return
 }
+
+// pullThumbnails is pullAttachments without downloading the media, the JPEG thumbnails that media messages carry
+// are saved instead.
+func (conn *Connection) pullThumbnails(m events.Message) (attachments []string, caption string, err error) {
+	var thumbnail []byte
+	if att := m.Message.GetImageMessage(); att != nil {
+		caption = att.GetCaption()
+		thumbnail = att.GetJPEGThumbnail()
+	}
+	if att := m.Message.GetVideoMessage(); att != nil {
+		caption = att.GetCaption()
+		thumbnail = att.GetJPEGThumbnail()
+	}
+	if att := m.Message.GetPtvMessage(); att != nil {
+		thumbnail = att.GetJPEGThumbnail()
+	}
+	if att := m.Message.GetDocumentMessage(); att != nil {
+		caption = att.GetCaption()
+		thumbnail = att.GetJPEGThumbnail()
+	}
+	fileName, err := conn.saveAttachment(thumbnail, "", ".jpeg")
+	if err != nil {
+		return attachments, caption, err
+	}
+	if fileName != "" {
+		attachments = append(attachments, fileName)
+	}
+	return attachments, caption, nil
+}
+
+// saveAttachment writes raw to the media path named by its hash, and returns the file name or "" if raw is empty.
+func (conn *Connection) saveAttachment(raw []byte, mimetype, fallbackExt string) (fileName string, err error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	fileName = conn.hashFile(raw)
+	exts, _ := mime.ExtensionsByType(mimetype)
+	if len(exts) > 0 {
+		fileName += exts[0]
+	} else {
+		fileName += fallbackExt
+	}
+	path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
+	if err := conn.writeFileIfNotExists(path, raw); err != nil {
+		return "", fmt.Errorf("failed to write attachment: %w", err)
+	}
+	return fileName, nil
+}
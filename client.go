@@ -18,6 +18,8 @@ type Connection struct {
 
 	Callbacks Callbacks
 
+	LinkPreviews LinkPreviewFetcher // when set, outgoing text containing a URL is sent with a link preview
+
 	client *whatsmeow.Client
 	ctx    context.Context
 }
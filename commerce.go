@@ -0,0 +1,115 @@
+package whatsmgr
+
+import (
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// Product is a snapshot of a catalog product as it was when shared, amounts are multiplied by 1000 to avoid rounding.
+type Product struct {
+	ProductID           *string `json:",omitempty"` // not always set
+	RetailerID          *string `json:",omitempty"` // the business' own ID for the product, not always set
+	Title               *string `json:",omitempty"` // not always set
+	Description         *string `json:",omitempty"` // not always set
+	CurrencyCode        *string `json:",omitempty"` // not always set
+	PriceAmount1000     *int64  `json:",omitempty"` // not always set
+	SalePriceAmount1000 *int64  `json:",omitempty"` // not always set
+	URL                 *string `json:",omitempty"` // not always set
+	ImageCount          *uint32 `json:",omitempty"` // the product image is saved in Message.Attachments, not always set
+	BusinessOwnerJID    *string `json:",omitempty"` // not always set
+	CatalogTitle        *string `json:",omitempty"` // set when a whole catalog is shared, not always set
+	CatalogDescription  *string `json:",omitempty"` // not always set
+}
+
+// Order is an order placed from a catalog, the items in it are not included.
+type Order struct {
+	OrderID           *string      `json:",omitempty"` // not always set
+	Title             *string      `json:",omitempty"` // not always set
+	ItemCount         *int32       `json:",omitempty"` // not always set
+	Status            *OrderStatus `json:",omitempty"` // use OrderStatus* constants, not always set
+	TotalAmount1000   *int64       `json:",omitempty"` // not always set
+	TotalCurrencyCode *string      `json:",omitempty"` // not always set
+	SellerJID         *string      `json:",omitempty"` // not always set
+	Token             *string      `json:",omitempty"` // needed to fetch the order details, not always set
+	RequestMessageID  *string      `json:",omitempty"` // the message the order is a response to, not always set
+}
+
+// Invoice is sent by a business for an order, the invoice image or PDF is saved in Message.Attachments.
+type Invoice struct {
+	Note           *string `json:",omitempty"` // not always set
+	Token          *string `json:",omitempty"` // not always set
+	AttachmentType *string `json:",omitempty"` // "image" or "pdf", not always set
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusInquiry  OrderStatus = "inquiry"
+	OrderStatusAccepted OrderStatus = "accepted"
+	OrderStatusDeclined OrderStatus = "declined"
+)
+
+func parseProductMessage(x *waE2E.ProductMessage) Product {
+	product := Product{
+		BusinessOwnerJID: x.BusinessOwnerJID,
+	}
+	if p := x.Product; p != nil {
+		product.ProductID = p.ProductID
+		product.RetailerID = p.RetailerID
+		product.Title = p.Title
+		product.Description = p.Description
+		product.CurrencyCode = p.CurrencyCode
+		product.PriceAmount1000 = p.PriceAmount1000
+		product.SalePriceAmount1000 = p.SalePriceAmount1000
+		product.URL = p.URL
+		product.ImageCount = p.ProductImageCount
+	}
+	if c := x.Catalog; c != nil {
+		product.CatalogTitle = c.Title
+		product.CatalogDescription = c.Description
+	}
+	return product
+}
+
+func parseOrderMessage(x *waE2E.OrderMessage) Order {
+	order := Order{
+		OrderID:           x.OrderID,
+		Title:             x.OrderTitle,
+		ItemCount:         x.ItemCount,
+		TotalAmount1000:   x.TotalAmount1000,
+		TotalCurrencyCode: x.TotalCurrencyCode,
+		SellerJID:         x.SellerJID,
+		Token:             x.Token,
+		RequestMessageID:  nonEmpty(x.GetOrderRequestMessageID().GetID()),
+	}
+	if x.Status != nil {
+		var status OrderStatus
+		switch *x.Status {
+		case waE2E.OrderMessage_INQUIRY:
+			status = OrderStatusInquiry
+		case waE2E.OrderMessage_ACCEPTED:
+			status = OrderStatusAccepted
+		case waE2E.OrderMessage_DECLINED:
+			status = OrderStatusDeclined
+		}
+		order.Status = &status
+	}
+	return order
+}
+
+func parseInvoiceMessage(x *waE2E.InvoiceMessage) Invoice {
+	invoice := Invoice{
+		Note:  x.Note,
+		Token: x.Token,
+	}
+	if x.AttachmentType != nil {
+		var attachmentType string
+		switch *x.AttachmentType {
+		case waE2E.InvoiceMessage_IMAGE:
+			attachmentType = "image"
+		case waE2E.InvoiceMessage_PDF:
+			attachmentType = "pdf"
+		}
+		invoice.AttachmentType = &attachmentType
+	}
+	return invoice
+}
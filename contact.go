@@ -51,6 +51,7 @@ type Group struct {
 	GroupAddParticipants         []GroupParticipant `json:",omitempty"` // add to existing participants, not always set
 	GroupRemovedParticipants     []GroupParticipant `json:",omitempty"` // remove from existing participants, not always set
 	GroupInviteLink              *string            `json:",omitempty"` // not always set
+	GroupPhoto                   *string            `json:",omitempty"` // a JPEG file name in the media path to set as the photo, or empty to remove it, only used by UpdateGroup
 }
 
 type GroupParticipant struct {
@@ -76,8 +77,12 @@ func (conn *Connection) pullProfilePhoto(jid types.JID) (imageName string, err e
 		return "", nil
 	}
 	conn.Callbacks.PushNewProfilePhotoID(jid.String(), info.ID)
+	return conn.saveProfilePhoto(info.URL)
+}
 
-	resp, err := http.Get(info.URL)
+// saveProfilePhoto downloads a profile photo, group photo or newsletter picture into the media path.
+func (conn *Connection) saveProfilePhoto(url string) (imageName string, err error) {
+	resp, err := http.Get(url)
 	if err != nil {
 		return "", fmt.Errorf("failed to download profile image: %w", err)
 	}
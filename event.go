@@ -0,0 +1,153 @@
+package whatsmgr
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// Event is a WhatsApp event, as used by groups and communities to schedule meetups. Edits, RSVPs and the cover
+// image are sent as separate messages, so they arrive as updates to the event message with only those fields set.
+type Event struct {
+	Name        *string `json:",omitempty"` // not always set
+	Description *string `json:",omitempty"` // not always set
+
+	LocationLat     *float64 `json:",omitempty"` // not always set
+	LocationLon     *float64 `json:",omitempty"` // not always set
+	LocationName    *string  `json:",omitempty"` // not always set
+	LocationAddress *string  `json:",omitempty"` // not always set
+	JoinLink        *string  `json:",omitempty"` // the call link for online events, not always set
+
+	StartTime *time.Time `json:",omitempty"` // not always set
+	EndTime   *time.Time `json:",omitempty"` // not always set
+
+	IsCanceled         *bool `json:",omitempty"` // not always set
+	ExtraGuestsAllowed *bool `json:",omitempty"` // not always set
+
+	CoverImage *string         `json:",omitempty"` // the saved cover image file name in the media path, only set on incoming events
+	Responses  []EventResponse `json:",omitempty"` // not always set
+}
+
+// EventResponse is the RSVP of one participant, a later response from the same participant replaces the earlier one.
+type EventResponse struct {
+	ResponderJID    string            `json:",omitempty"`
+	Response        EventResponseType `json:",omitempty"` // use EventResponseType* constants
+	ExtraGuestCount *int32            `json:",omitempty"` // not always set
+	Timestamp       *time.Time        `json:",omitempty"` // not always set
+}
+
+type EventResponseType string
+
+const (
+	EventResponseTypeGoing    EventResponseType = "going"
+	EventResponseTypeNotGoing EventResponseType = "not-going"
+	EventResponseTypeMaybe    EventResponseType = "maybe"
+)
+
+func parseEvent(x *waE2E.EventMessage) Event {
+	event := Event{
+		Name:               x.Name,
+		Description:        x.Description,
+		JoinLink:           nonEmpty(x.GetJoinLink()),
+		IsCanceled:         x.IsCanceled,
+		ExtraGuestsAllowed: x.ExtraGuestsAllowed,
+	}
+	if location := x.GetLocation(); location != nil {
+		event.LocationLat = location.DegreesLatitude
+		event.LocationLon = location.DegreesLongitude
+		event.LocationName = nonEmpty(location.GetName())
+		event.LocationAddress = nonEmpty(location.GetAddress())
+	}
+	if x.GetStartTime() > 0 {
+		start := time.Unix(x.GetStartTime(), 0)
+		event.StartTime = &start
+	}
+	if x.GetEndTime() > 0 {
+		end := time.Unix(x.GetEndTime(), 0)
+		event.EndTime = &end
+	}
+	return event
+}
+
+// decryptEventResponse decrypts an RSVP with the secret of the event message it responds to, which whatsmeow
+// stored when the event was received or sent.
+func (conn *Connection) decryptEventResponse(m events.Message) (EventResponse, error) {
+	x := m.Message.GetEncEventResponseMessage()
+	response := EventResponse{
+		ResponderJID: m.Info.Sender.ToNonAD().String(),
+	}
+	if x.GetEventCreationMessageKey().GetID() == "" || len(x.GetEncPayload()) == 0 {
+		return response, errors.New("missing event creation message key or encrypted payload")
+	}
+	// whatsmeow only exposes the generic message secret decryption through its internals
+	plaintext, err := conn.client.DangerousInternals().DecryptMsgSecret(context.Background(), &m, whatsmeow.EncSecretEventResponse, x, x.GetEventCreationMessageKey())
+	if err != nil {
+		return response, fmt.Errorf("failed to decrypt event response: %w", err)
+	}
+	var decrypted waE2E.EventResponseMessage
+	if err := proto.Unmarshal(plaintext, &decrypted); err != nil {
+		return response, fmt.Errorf("failed to decode event response: %w", err)
+	}
+	switch decrypted.GetResponse() {
+	case waE2E.EventResponseMessage_GOING:
+		response.Response = EventResponseTypeGoing
+	case waE2E.EventResponseMessage_NOT_GOING:
+		response.Response = EventResponseTypeNotGoing
+	case waE2E.EventResponseMessage_MAYBE:
+		response.Response = EventResponseTypeMaybe
+	default:
+		return response, fmt.Errorf("unknown event response: %s", decrypted.GetResponse())
+	}
+	response.ExtraGuestCount = decrypted.ExtraGuestCount
+	if decrypted.GetTimestampMS() > 0 {
+		timestamp := time.UnixMilli(decrypted.GetTimestampMS())
+		response.Timestamp = &timestamp
+	}
+	return response, nil
+}
+
+// buildEventMessage builds an event creation message, the message secret lets participants encrypt their RSVPs.
+func buildEventMessage(event Event) (*waE2E.Message, error) {
+	if event.Name == nil || *event.Name == "" {
+		return nil, errors.New("missing message.Event.Name")
+	}
+	if event.StartTime == nil {
+		return nil, errors.New("missing message.Event.StartTime")
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate message secret: %w", err)
+	}
+	out := &waE2E.EventMessage{
+		Name:               event.Name,
+		Description:        event.Description,
+		JoinLink:           event.JoinLink,
+		StartTime:          proto.Int64(event.StartTime.Unix()),
+		IsCanceled:         proto.Bool(event.IsCanceled != nil && *event.IsCanceled),
+		ExtraGuestsAllowed: proto.Bool(event.ExtraGuestsAllowed != nil && *event.ExtraGuestsAllowed),
+	}
+	if event.EndTime != nil {
+		out.EndTime = proto.Int64(event.EndTime.Unix())
+	}
+	if event.LocationName != nil || event.LocationAddress != nil || (event.LocationLat != nil && event.LocationLon != nil) {
+		out.Location = &waE2E.LocationMessage{
+			DegreesLatitude:  event.LocationLat,
+			DegreesLongitude: event.LocationLon,
+			Name:             event.LocationName,
+			Address:          event.LocationAddress,
+		}
+	}
+	return &waE2E.Message{
+		EventMessage: out,
+		MessageContextInfo: &waE2E.MessageContextInfo{
+			MessageSecret: secret,
+		},
+	}, nil
+}
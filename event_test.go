@@ -0,0 +1,37 @@
+package whatsmgr
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBuildEventMessage(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	event := Event{
+		Name:         proto.String("Braai at the park"),
+		LocationName: proto.String("Green Point Park"),
+		StartTime:    &start,
+		EndTime:      &end,
+	}
+	out, err := buildEventMessage(event)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(out.GetMessageContextInfo().GetMessageSecret()) != 32 {
+		t.Errorf("Expected a 32 byte message secret, got %d bytes", len(out.GetMessageContextInfo().GetMessageSecret()))
+	}
+	parsed := parseEvent(out.GetEventMessage())
+	if stringOrEmpty(parsed.Name) != "Braai at the park" || stringOrEmpty(parsed.LocationName) != "Green Point Park" {
+		t.Errorf("Unexpected event: %+v", parsed)
+	}
+	if parsed.StartTime == nil || !parsed.StartTime.Equal(start) || parsed.EndTime == nil || !parsed.EndTime.Equal(end) {
+		t.Errorf("Expected %s to %s, got %v to %v", start, end, parsed.StartTime, parsed.EndTime)
+	}
+
+	if _, err := buildEventMessage(Event{Name: proto.String("No start")}); err == nil {
+		t.Errorf("Expected an error for an event without a start time")
+	}
+}
@@ -27,3 +27,11 @@ func (conn *Connection) writeFileIfNotExists(path string, data []byte) error {
 	_, err = f.Write(data)
 	return err
 }
+
+func (conn *Connection) readPicture(fileName string) ([]byte, error) {
+	raw, err := os.ReadFile(fmt.Sprintf("%s/%s", conn.MediaPath, fileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read picture: %w", err)
+	}
+	return raw, nil
+}
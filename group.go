@@ -0,0 +1,480 @@
+package whatsmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GroupParticipantResult is the outcome of adding, removing, promoting or demoting one participant.
+type GroupParticipantResult struct {
+	UserJID string
+
+	Error             *int  `json:",omitempty"` // the WhatsApp error code when the change failed (e.g. 403, 408 or 409), or -1 when WhatsApp didn't report on the participant, not always set
+	PrivacyRestricted *bool `json:",omitempty"` // the user's privacy settings don't allow us to add them, not always set
+
+	InviteCode   *string    `json:",omitempty"` // send the user a group invite with this code instead, not always set
+	InviteExpiry *time.Time `json:",omitempty"` // not always set
+}
+
+// GroupInvite is an invite message to join a group, which can be previewed with GetGroupFromInvite and accepted
+// with JoinGroupWithInvite.
+type GroupInvite struct {
+	GroupJID   string     `json:",omitempty"`
+	GroupName  *string    `json:",omitempty"` // not always set
+	InviteCode *string    `json:",omitempty"` // not always set
+	Expiry     *time.Time `json:",omitempty"` // not always set
+	InviterJID string     `json:",omitempty"` // the admin who sent the invite
+}
+
+// GroupJoinRequest is a request to join a group that requires approval. Requests that are withdrawn by the user, or
+// handled by another admin, arrive again with Revoked set.
+type GroupJoinRequest struct {
+	GroupJID    string     `json:",omitempty"`
+	UserJID     string     `json:",omitempty"`
+	RequestedAt *time.Time `json:",omitempty"` // not always set
+	Method      *string    `json:",omitempty"` // how the user asked to join, like "invite_link" or "non_admin_add", not always set
+	Revoked     *bool      `json:",omitempty"` // not always set
+}
+
+// groupErrorPrivacy is the error code WhatsApp returns for participants whose privacy settings don't allow them to
+// be added to groups by us.
+const groupErrorPrivacy = 403
+
+// groupErrorNotReported is the error code given to participants that WhatsApp left out of its response, so it is
+// unknown whether the change applied to them. It isn't a WhatsApp error code.
+const groupErrorNotReported = -1
+
+// parseGroupInfo converts the group info returned by whatsmeow to a contact, without the profile photo.
+func parseGroupInfo(info *types.GroupInfo) Contact {
+	parentJID := info.LinkedParentJID.String()
+	onlyAdminsCanAddMembers := info.MemberAddMode == types.GroupMemberAddModeAdmin
+	isGroup := true
+	contact := Contact{
+		JID:     info.JID.String(),
+		IsGroup: &isGroup,
+		Group: Group{
+			GroupName:                    &info.Name,
+			GroupTopic:                   &info.Topic,
+			GroupInfoLockedToAdmins:      &info.IsLocked,
+			GroupOnlyAdminCanMessage:     &info.IsAnnounce,
+			GroupIsParent:                &info.IsParent,
+			GroupLinkedParentJID:         &parentJID,
+			GroupIsDefaultSubGroup:       &info.IsDefaultSubGroup,
+			GroupJoinApprovalRequired:    &info.IsJoinApprovalRequired,
+			GroupCreated:                 &info.GroupCreated,
+			GroupOnlyAdminsCanAddMembers: &onlyAdminsCanAddMembers,
+		},
+	}
+	groupParticipants := []GroupParticipant{}
+	for _, par := range info.Participants {
+		groupParticipant := GroupParticipant{
+			UserJID: par.JID.String(),
+		}
+		groupParticipant.Rank = GroupParticipantRankRegular
+		if par.IsAdmin {
+			groupParticipant.Rank = GroupParticipantRankAdmin
+		} else if par.IsSuperAdmin {
+			groupParticipant.Rank = GroupParticipantRankSuperAdmin
+		}
+		groupParticipants = append(groupParticipants, groupParticipant)
+	}
+	if len(groupParticipants) > 0 {
+		contact.GroupReplaceParticipants = groupParticipants
+	}
+	return contact
+}
+
+// participantResults picks the results for the requested participants out of the participants returned by
+// WhatsApp, which may refer to them by either their phone number or LID. Requested participants that WhatsApp left
+// out get a groupErrorNotReported result.
+func participantResults(requested []types.JID, participants []types.GroupParticipant) []GroupParticipantResult {
+	wanted := map[types.JID]bool{}
+	for _, jid := range requested {
+		wanted[jid.ToNonAD()] = true
+	}
+	reported := map[types.JID]bool{}
+	results := []GroupParticipantResult{}
+	for _, par := range participants {
+		if !wanted[par.JID.ToNonAD()] && !wanted[par.PhoneNumber.ToNonAD()] && !wanted[par.LID.ToNonAD()] {
+			continue
+		}
+		reported[par.JID.ToNonAD()] = true
+		reported[par.PhoneNumber.ToNonAD()] = true
+		reported[par.LID.ToNonAD()] = true
+		result := GroupParticipantResult{
+			UserJID: par.JID.String(),
+		}
+		if par.Error != 0 {
+			errorCode := par.Error
+			privacyRestricted := errorCode == groupErrorPrivacy
+			result.Error = &errorCode
+			result.PrivacyRestricted = &privacyRestricted
+		}
+		if par.AddRequest != nil {
+			result.InviteCode = nonEmpty(par.AddRequest.Code)
+			if !par.AddRequest.Expiration.IsZero() {
+				expiry := par.AddRequest.Expiration
+				result.InviteExpiry = &expiry
+			}
+		}
+		results = append(results, result)
+	}
+	for _, jid := range requested {
+		if reported[jid.ToNonAD()] {
+			continue
+		}
+		errorCode := groupErrorNotReported
+		results = append(results, GroupParticipantResult{
+			UserJID: jid.String(),
+			Error:   &errorCode,
+		})
+	}
+	return results
+}
+
+func parseParticipantJIDs(participants []string) ([]types.JID, error) {
+	jids := make([]types.JID, 0, len(participants))
+	for _, participant := range participants {
+		jid, err := types.ParseJID(participant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse participant ('%s'): %w", participant, err)
+		}
+		jids = append(jids, jid)
+	}
+	return jids, nil
+}
+
+// CreateGroup creates a group with the given participants, we are added as the owner. Participants that couldn't
+// be added are reported in the results and left out of the returned contact.
+func (conn *Connection) CreateGroup(name string, participants []string) (Contact, []GroupParticipantResult, error) {
+	jids, err := parseParticipantJIDs(participants)
+	if err != nil {
+		return Contact{}, nil, err
+	}
+	info, err := conn.client.CreateGroup(context.Background(), whatsmeow.ReqCreateGroup{
+		Name:         name,
+		Participants: jids,
+	})
+	if err != nil {
+		return Contact{}, nil, fmt.Errorf("failed to create group: %w", err)
+	}
+	results := participantResults(jids, info.Participants)
+	added := *info
+	added.Participants = nil
+	for _, par := range info.Participants {
+		if par.Error == 0 {
+			added.Participants = append(added.Participants, par)
+		}
+	}
+	return parseGroupInfo(&added), results, nil
+}
+
+// AddParticipants adds participants to a group, see GroupParticipantResult for those that couldn't be added.
+func (conn *Connection) AddParticipants(groupJID string, participants []string) ([]GroupParticipantResult, error) {
+	return conn.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangeAdd)
+}
+
+// RemoveParticipants removes participants from a group, see GroupParticipantResult for those that couldn't be removed.
+func (conn *Connection) RemoveParticipants(groupJID string, participants []string) ([]GroupParticipantResult, error) {
+	return conn.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangeRemove)
+}
+
+// PromoteParticipants makes participants admins of a group.
+func (conn *Connection) PromoteParticipants(groupJID string, participants []string) ([]GroupParticipantResult, error) {
+	return conn.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangePromote)
+}
+
+// DemoteParticipants makes admins regular participants of a group.
+func (conn *Connection) DemoteParticipants(groupJID string, participants []string) ([]GroupParticipantResult, error) {
+	return conn.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangeDemote)
+}
+
+func (conn *Connection) updateParticipants(groupJID string, participants []string, action whatsmeow.ParticipantChange) ([]GroupParticipantResult, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse groupJID ('%s'): %w", groupJID, err)
+	}
+	jids, err := parseParticipantJIDs(participants)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := conn.client.UpdateGroupParticipants(jid, jids, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s group participants: %w", action, err)
+	}
+	return participantResults(jids, updated), nil
+}
+
+// groupSetter is the part of the whatsmeow client used by UpdateGroup.
+type groupSetter interface {
+	SetGroupName(jid types.JID, name string) error
+	SetGroupTopic(jid types.JID, previousID, newID, topic string) error
+	SetGroupAnnounce(jid types.JID, announce bool) error
+	SetGroupLocked(jid types.JID, locked bool) error
+	SetGroupMemberAddMode(jid types.JID, mode types.GroupMemberAddMode) error
+	SetGroupJoinApprovalMode(jid types.JID, mode bool) error
+	SetGroupPhoto(jid types.JID, avatar []byte) (string, error)
+}
+
+// UpdateGroup changes the settings of a group we administer, only the fields of group that are set are changed.
+// WhatsApp has no way to change them at once, so they are changed one at a time and UpdateGroup stops at the first
+// error, the returned Group has the fields that were changed before it. The changes arrive through the Contact
+// callback once WhatsApp applies them.
+func (conn *Connection) UpdateGroup(groupJID string, group Group) (Group, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to parse groupJID ('%s'): %w", groupJID, err)
+	}
+	var photo []byte
+	if group.GroupPhoto != nil && *group.GroupPhoto != "" {
+		photo, err = conn.readPicture(*group.GroupPhoto)
+		if err != nil {
+			return Group{}, err
+		}
+	}
+	return updateGroup(conn.client, jid, group, photo)
+}
+
+func updateGroup(setter groupSetter, jid types.JID, group Group, photo []byte) (Group, error) {
+	applied := Group{}
+	if group.GroupName == nil && group.GroupTopic == nil && group.GroupOnlyAdminCanMessage == nil &&
+		group.GroupInfoLockedToAdmins == nil && group.GroupOnlyAdminsCanAddMembers == nil &&
+		group.GroupJoinApprovalRequired == nil && group.GroupPhoto == nil {
+		return applied, errors.New("nothing to update")
+	}
+	if group.GroupName != nil {
+		if err := setter.SetGroupName(jid, *group.GroupName); err != nil {
+			return applied, fmt.Errorf("failed to set group name: %w", err)
+		}
+		applied.GroupName = group.GroupName
+	}
+	if group.GroupTopic != nil {
+		if err := setter.SetGroupTopic(jid, "", "", *group.GroupTopic); err != nil {
+			return applied, fmt.Errorf("failed to set group topic: %w", err)
+		}
+		applied.GroupTopic = group.GroupTopic
+	}
+	if group.GroupOnlyAdminCanMessage != nil {
+		if err := setter.SetGroupAnnounce(jid, *group.GroupOnlyAdminCanMessage); err != nil {
+			return applied, fmt.Errorf("failed to set group announce: %w", err)
+		}
+		applied.GroupOnlyAdminCanMessage = group.GroupOnlyAdminCanMessage
+	}
+	if group.GroupInfoLockedToAdmins != nil {
+		if err := setter.SetGroupLocked(jid, *group.GroupInfoLockedToAdmins); err != nil {
+			return applied, fmt.Errorf("failed to set group locked: %w", err)
+		}
+		applied.GroupInfoLockedToAdmins = group.GroupInfoLockedToAdmins
+	}
+	if group.GroupOnlyAdminsCanAddMembers != nil {
+		mode := types.GroupMemberAddModeAllMember
+		if *group.GroupOnlyAdminsCanAddMembers {
+			mode = types.GroupMemberAddModeAdmin
+		}
+		if err := setter.SetGroupMemberAddMode(jid, mode); err != nil {
+			return applied, fmt.Errorf("failed to set group member add mode: %w", err)
+		}
+		applied.GroupOnlyAdminsCanAddMembers = group.GroupOnlyAdminsCanAddMembers
+	}
+	if group.GroupJoinApprovalRequired != nil {
+		if err := setter.SetGroupJoinApprovalMode(jid, *group.GroupJoinApprovalRequired); err != nil {
+			return applied, fmt.Errorf("failed to set group join approval mode: %w", err)
+		}
+		applied.GroupJoinApprovalRequired = group.GroupJoinApprovalRequired
+	}
+	if group.GroupPhoto != nil {
+		// an empty photo removes it
+		if _, err := setter.SetGroupPhoto(jid, photo); err != nil {
+			return applied, fmt.Errorf("failed to set group photo: %w", err)
+		}
+		applied.GroupPhoto = group.GroupPhoto
+	}
+	return applied, nil
+}
+
+// GetGroupInviteLink fetches the https://chat.whatsapp.com/ invite link of a group we administer, reset revokes the
+// current link and creates a new one.
+func (conn *Connection) GetGroupInviteLink(groupJID string, reset bool) (string, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse groupJID ('%s'): %w", groupJID, err)
+	}
+	link, err := conn.client.GetGroupInviteLink(jid, reset)
+	if err != nil {
+		return "", fmt.Errorf("failed to get group invite link: %w", err)
+	}
+	return link, nil
+}
+
+// GetGroupFromInviteLink fetches a group by its invite link or code, without joining it.
+func (conn *Connection) GetGroupFromInviteLink(invite string) (Contact, error) {
+	info, err := conn.client.GetGroupInfoFromLink(invite)
+	if err != nil {
+		return Contact{}, fmt.Errorf("failed to get group info from invite link: %w", err)
+	}
+	return parseGroupInfo(info), nil
+}
+
+// JoinGroupWithLink joins a group by its invite link or code and returns the group JID. If the group requires
+// approval a join request is sent instead.
+func (conn *Connection) JoinGroupWithLink(invite string) (string, error) {
+	jid, err := conn.client.JoinGroupWithLink(invite)
+	if err != nil {
+		return "", fmt.Errorf("failed to join group with invite link: %w", err)
+	}
+	return jid.String(), nil
+}
+
+// GetGroupFromInvite fetches the group of an invite message, without joining it.
+func (conn *Connection) GetGroupFromInvite(invite GroupInvite) (Contact, error) {
+	jid, inviter, expiration, err := parseGroupInvite(invite)
+	if err != nil {
+		return Contact{}, err
+	}
+	info, err := conn.client.GetGroupInfoFromInvite(jid, inviter, *invite.InviteCode, expiration)
+	if err != nil {
+		return Contact{}, fmt.Errorf("failed to get group info from invite: %w", err)
+	}
+	return parseGroupInfo(info), nil
+}
+
+// JoinGroupWithInvite joins the group of an invite message.
+func (conn *Connection) JoinGroupWithInvite(invite GroupInvite) error {
+	jid, inviter, expiration, err := parseGroupInvite(invite)
+	if err != nil {
+		return err
+	}
+	if err := conn.client.JoinGroupWithInvite(jid, inviter, *invite.InviteCode, expiration); err != nil {
+		return fmt.Errorf("failed to join group with invite: %w", err)
+	}
+	return nil
+}
+
+func parseGroupInvite(invite GroupInvite) (jid types.JID, inviter types.JID, expiration int64, err error) {
+	if invite.InviteCode == nil || *invite.InviteCode == "" {
+		return jid, inviter, 0, errors.New("missing invite.InviteCode")
+	}
+	jid, err = types.ParseJID(invite.GroupJID)
+	if err != nil {
+		return jid, inviter, 0, fmt.Errorf("failed to parse invite.GroupJID ('%s'): %w", invite.GroupJID, err)
+	}
+	inviter, err = types.ParseJID(invite.InviterJID)
+	if err != nil {
+		return jid, inviter, 0, fmt.Errorf("failed to parse invite.InviterJID ('%s'): %w", invite.InviterJID, err)
+	}
+	if invite.Expiry != nil {
+		expiration = invite.Expiry.Unix()
+	}
+	return jid, inviter, expiration, nil
+}
+
+func (conn *Connection) handleGroupJoinRequest(request GroupJoinRequest) {
+	if conn.Callbacks.GroupJoinRequest == nil {
+		return
+	}
+	conn.Callbacks.GroupJoinRequest(request)
+}
+
+// parseGroupJoinRequests reads the join request changes of a group notification, which whatsmeow doesn't parse and
+// passes on as unknown changes. A user requesting to join, or withdrawing their own request, is only the sender of
+// the notification, while other changes list the users as participant children.
+func parseGroupJoinRequests(groupJID types.JID, sender *types.JID, timestamp time.Time, changes []*waBinary.Node) []GroupJoinRequest {
+	requests := []GroupJoinRequest{}
+	for _, change := range changes {
+		if change == nil {
+			continue
+		}
+		var revoked bool
+		switch change.Tag {
+		case "created_membership_requests":
+		case "revoked_membership_requests":
+			revoked = true
+		default:
+			continue
+		}
+		method := nonEmpty(change.AttrGetter().OptionalString("request_method"))
+		users := []types.JID{}
+		for _, child := range change.GetChildren() {
+			if jid := child.AttrGetter().OptionalJIDOrEmpty("jid"); !jid.IsEmpty() {
+				users = append(users, jid)
+			}
+		}
+		if len(users) == 0 && sender != nil && !sender.IsEmpty() {
+			users = append(users, sender.ToNonAD())
+		}
+		for _, jid := range users {
+			request := GroupJoinRequest{
+				GroupJID: groupJID.String(),
+				UserJID:  jid.String(),
+				Method:   method,
+				Revoked:  &revoked,
+			}
+			if !revoked && !timestamp.IsZero() {
+				requestedAt := timestamp
+				request.RequestedAt = &requestedAt
+			}
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// GetGroupJoinRequests fetches the pending requests to join a group we administer. WhatsApp doesn't say how they
+// were requested here, so Method and Revoked are not set.
+func (conn *Connection) GetGroupJoinRequests(groupJID string) ([]GroupJoinRequest, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse groupJID ('%s'): %w", groupJID, err)
+	}
+	pending, err := conn.client.GetGroupRequestParticipants(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group join requests: %w", err)
+	}
+	requests := []GroupJoinRequest{}
+	for _, request := range pending {
+		groupJoinRequest := GroupJoinRequest{
+			GroupJID: jid.String(),
+			UserJID:  request.JID.String(),
+		}
+		if !request.RequestedAt.IsZero() {
+			requestedAt := request.RequestedAt
+			groupJoinRequest.RequestedAt = &requestedAt
+		}
+		requests = append(requests, groupJoinRequest)
+	}
+	return requests, nil
+}
+
+// ApproveGroupJoinRequests adds the users who requested to join a group, see GroupParticipantResult for those that
+// couldn't be added.
+func (conn *Connection) ApproveGroupJoinRequests(groupJID string, users []string) ([]GroupParticipantResult, error) {
+	return conn.updateJoinRequests(groupJID, users, whatsmeow.ParticipantChangeApprove)
+}
+
+// RejectGroupJoinRequests declines the requests of users to join a group, see GroupParticipantResult for those that
+// couldn't be rejected.
+func (conn *Connection) RejectGroupJoinRequests(groupJID string, users []string) ([]GroupParticipantResult, error) {
+	return conn.updateJoinRequests(groupJID, users, whatsmeow.ParticipantChangeReject)
+}
+
+func (conn *Connection) updateJoinRequests(groupJID string, users []string, action whatsmeow.ParticipantRequestChange) ([]GroupParticipantResult, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse groupJID ('%s'): %w", groupJID, err)
+	}
+	jids, err := parseParticipantJIDs(users)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := conn.client.UpdateGroupRequestParticipants(jid, jids, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s group join requests: %w", action, err)
+	}
+	return participantResults(jids, updated), nil
+}
@@ -0,0 +1,240 @@
+package whatsmgr
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseGroupInfo(t *testing.T) {
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	admin := types.NewJID("27820000000", types.DefaultUserServer)
+	member := types.NewJID("27820000001", types.DefaultUserServer)
+
+	contact := parseGroupInfo(&types.GroupInfo{
+		JID:           group,
+		GroupName:     types.GroupName{Name: "Weekend specials"},
+		GroupAnnounce: types.GroupAnnounce{IsAnnounce: true},
+		MemberAddMode: types.GroupMemberAddModeAdmin,
+		Participants: []types.GroupParticipant{
+			{JID: admin, IsAdmin: true},
+			{JID: member},
+		},
+	})
+
+	if contact.JID != group.String() || contact.IsGroup == nil || !*contact.IsGroup {
+		t.Errorf("Expected group %s, got %s %v", group, contact.JID, contact.IsGroup)
+	}
+	if stringOrEmpty(contact.GroupName) != "Weekend specials" {
+		t.Errorf("Expected GroupName \"Weekend specials\", got %q", stringOrEmpty(contact.GroupName))
+	}
+	if contact.GroupOnlyAdminCanMessage == nil || !*contact.GroupOnlyAdminCanMessage {
+		t.Errorf("Expected GroupOnlyAdminCanMessage, got %v", contact.GroupOnlyAdminCanMessage)
+	}
+	if contact.GroupOnlyAdminsCanAddMembers == nil || !*contact.GroupOnlyAdminsCanAddMembers {
+		t.Errorf("Expected GroupOnlyAdminsCanAddMembers, got %v", contact.GroupOnlyAdminsCanAddMembers)
+	}
+	if contact.ProfilePhoto != nil {
+		t.Errorf("Expected no ProfilePhoto, got %v", *contact.ProfilePhoto)
+	}
+	if len(contact.GroupReplaceParticipants) != 2 {
+		t.Fatalf("Expected 2 participants, got %d", len(contact.GroupReplaceParticipants))
+	}
+	if contact.GroupReplaceParticipants[0].Rank != GroupParticipantRankAdmin {
+		t.Errorf("Expected %s to be an admin, got %s", admin, contact.GroupReplaceParticipants[0].Rank)
+	}
+	if contact.GroupReplaceParticipants[1].Rank != GroupParticipantRankRegular {
+		t.Errorf("Expected %s to be regular, got %s", member, contact.GroupReplaceParticipants[1].Rank)
+	}
+}
+
+func TestParticipantResults(t *testing.T) {
+	added := types.NewJID("27820000001", types.DefaultUserServer)
+	private := types.NewJID("27820000002", types.DefaultUserServer)
+	privateLID := types.NewJID("100000000000002", types.HiddenUserServer)
+	expiry := time.Date(2025, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	missing := types.NewJID("27820000003", types.DefaultUserServer)
+
+	results := participantResults([]types.JID{added, private, missing}, []types.GroupParticipant{
+		{JID: types.NewJID("27820000000", types.DefaultUserServer), IsAdmin: true, IsSuperAdmin: true},
+		{JID: added, PhoneNumber: added},
+		{
+			JID:         privateLID,
+			PhoneNumber: private,
+			LID:         privateLID,
+			Error:       403,
+			AddRequest:  &types.GroupParticipantAddRequest{Code: "AbCdEf123", Expiration: expiry},
+		},
+	})
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].UserJID != added.String() || results[0].Error != nil {
+		t.Errorf("Expected %s to be added without error, got %+v", added, results[0])
+	}
+	privateResult := results[1]
+	if privateResult.UserJID != privateLID.String() {
+		t.Errorf("Expected UserJID %s, got %s", privateLID, privateResult.UserJID)
+	}
+	if privateResult.Error == nil || *privateResult.Error != 403 {
+		t.Errorf("Expected Error 403, got %v", privateResult.Error)
+	}
+	if privateResult.PrivacyRestricted == nil || !*privateResult.PrivacyRestricted {
+		t.Errorf("Expected PrivacyRestricted, got %v", privateResult.PrivacyRestricted)
+	}
+	if privateResult.InviteCode == nil || *privateResult.InviteCode != "AbCdEf123" {
+		t.Errorf("Expected InviteCode AbCdEf123, got %v", privateResult.InviteCode)
+	}
+	if privateResult.InviteExpiry == nil || !privateResult.InviteExpiry.Equal(expiry) {
+		t.Errorf("Expected InviteExpiry %s, got %v", expiry, privateResult.InviteExpiry)
+	}
+	if results[2].UserJID != missing.String() || results[2].Error == nil || *results[2].Error != groupErrorNotReported {
+		t.Errorf("Expected %s to be reported as missing, got %+v", missing, results[2])
+	}
+}
+
+// fakeGroupSetter records the group settings that were set, and fails to set the photo when photoErr is set.
+type fakeGroupSetter struct {
+	calls    []string
+	photoErr error
+}
+
+func (f *fakeGroupSetter) SetGroupName(jid types.JID, name string) error {
+	f.calls = append(f.calls, "name "+name)
+	return nil
+}
+
+func (f *fakeGroupSetter) SetGroupTopic(jid types.JID, previousID, newID, topic string) error {
+	f.calls = append(f.calls, "topic "+topic)
+	return nil
+}
+
+func (f *fakeGroupSetter) SetGroupAnnounce(jid types.JID, announce bool) error {
+	f.calls = append(f.calls, "announce")
+	return nil
+}
+
+func (f *fakeGroupSetter) SetGroupLocked(jid types.JID, locked bool) error {
+	f.calls = append(f.calls, "locked")
+	return nil
+}
+
+func (f *fakeGroupSetter) SetGroupMemberAddMode(jid types.JID, mode types.GroupMemberAddMode) error {
+	f.calls = append(f.calls, "member add mode "+string(mode))
+	return nil
+}
+
+func (f *fakeGroupSetter) SetGroupJoinApprovalMode(jid types.JID, mode bool) error {
+	f.calls = append(f.calls, "join approval")
+	return nil
+}
+
+func (f *fakeGroupSetter) SetGroupPhoto(jid types.JID, avatar []byte) (string, error) {
+	f.calls = append(f.calls, "photo")
+	return "", f.photoErr
+}
+
+func TestUpdateGroup(t *testing.T) {
+	group := types.NewJID("120363000000000001", types.GroupServer)
+
+	setter := &fakeGroupSetter{}
+	applied, err := updateGroup(setter, group, Group{
+		GroupName:                    proto.String("Weekend specials"),
+		GroupOnlyAdminsCanAddMembers: proto.Bool(true),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(setter.calls) != 2 || setter.calls[0] != "name Weekend specials" || setter.calls[1] != "member add mode admin_add" {
+		t.Errorf("Unexpected calls: %v", setter.calls)
+	}
+	if stringOrEmpty(applied.GroupName) != "Weekend specials" || applied.GroupOnlyAdminsCanAddMembers == nil || applied.GroupTopic != nil {
+		t.Errorf("Unexpected applied fields: %+v", applied)
+	}
+
+	setter = &fakeGroupSetter{photoErr: errors.New("not an admin")}
+	applied, err = updateGroup(setter, group, Group{
+		GroupTopic: proto.String("Open all weekend"),
+		GroupPhoto: proto.String(""),
+	}, nil)
+	if err == nil {
+		t.Errorf("Expected the photo error, got nil")
+	}
+	if stringOrEmpty(applied.GroupTopic) != "Open all weekend" || applied.GroupPhoto != nil {
+		t.Errorf("Expected only the topic to be applied, got %+v", applied)
+	}
+
+	setter = &fakeGroupSetter{}
+	if _, err := updateGroup(setter, group, Group{}, nil); err == nil || len(setter.calls) != 0 {
+		t.Errorf("Expected an error and no calls for an empty group, got %v %v", err, setter.calls)
+	}
+}
+
+func TestParseGroupJoinRequests(t *testing.T) {
+	group := types.NewJID("120363000000000002", types.GroupServer)
+	requester := types.NewJID("100000000000003", types.HiddenUserServer)
+	withdrawn := types.NewJID("100000000000004", types.HiddenUserServer)
+	sender := types.NewJID("100000000000005", types.HiddenUserServer)
+	sender.Device = 1
+	timestamp := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	requests := parseGroupJoinRequests(group, nil, timestamp, []*waBinary.Node{
+		{
+			Tag:   "created_membership_requests",
+			Attrs: waBinary.Attrs{"request_method": "invite_link"},
+			Content: []waBinary.Node{
+				{Tag: "participant", Attrs: waBinary.Attrs{"jid": requester}},
+			},
+		},
+		{
+			Tag: "revoked_membership_requests",
+			Content: []waBinary.Node{
+				{Tag: "participant", Attrs: waBinary.Attrs{"jid": withdrawn}},
+			},
+		},
+		{Tag: "some_other_change"},
+	})
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	created := requests[0]
+	if created.GroupJID != group.String() || created.UserJID != requester.String() {
+		t.Errorf("Expected request from %s to join %s, got %+v", requester, group, created)
+	}
+	if created.Method == nil || *created.Method != "invite_link" {
+		t.Errorf("Expected Method invite_link, got %v", created.Method)
+	}
+	if created.Revoked == nil || *created.Revoked {
+		t.Errorf("Expected Revoked false, got %v", created.Revoked)
+	}
+	if created.RequestedAt == nil || !created.RequestedAt.Equal(timestamp) {
+		t.Errorf("Expected RequestedAt %s, got %v", timestamp, created.RequestedAt)
+	}
+	revoked := requests[1]
+	if revoked.UserJID != withdrawn.String() {
+		t.Errorf("Expected UserJID %s, got %s", withdrawn, revoked.UserJID)
+	}
+	if revoked.Revoked == nil || !*revoked.Revoked {
+		t.Errorf("Expected Revoked true, got %v", revoked.Revoked)
+	}
+	if revoked.RequestedAt != nil {
+		t.Errorf("Expected no RequestedAt, got %v", revoked.RequestedAt)
+	}
+
+	requests = parseGroupJoinRequests(group, &sender, timestamp, []*waBinary.Node{
+		{Tag: "created_membership_requests", Attrs: waBinary.Attrs{"request_method": "invite_link"}},
+	})
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request from the sender, got %d", len(requests))
+	}
+	if requests[0].UserJID != sender.ToNonAD().String() {
+		t.Errorf("Expected UserJID %s, got %s", sender.ToNonAD(), requests[0].UserJID)
+	}
+}
@@ -19,6 +19,11 @@ type Callbacks struct {
 	Message func(Message)
 	Call    func(Call)
 	User    func(User)
+	Status  func(Status) // optional, status updates are ignored when not set
+
+	Newsletter func(Newsletter) // optional, newsletter updates are ignored when not set
+
+	GroupJoinRequest func(GroupJoinRequest) // optional, join requests are ignored when not set
 
 	GetExistingProfilePhotoID func(jid string) (photoID string)
 	PushNewProfilePhotoID     func(jid, photoID string)
@@ -417,7 +422,7 @@ func (conn *Connection) handleEvent(rawEvt any) {
 	case *events.UndecryptableMessage:
 		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
 	case *events.NewsletterMessageMeta:
-		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
+		// this is only sent as part of events.Message, see parseEventMessage
 	case *events.Message:
 		if evt != nil {
 			conn.handleMessage(*evt)
@@ -497,25 +502,7 @@ func (conn *Connection) handleEvent(rawEvt any) {
 		}
 		conn.Callbacks.Contact(contact)
 	case *events.JoinedGroup:
-		parentJID := evt.GroupInfo.LinkedParentJID.String()
-		onlyAdminsCanAddMembers := evt.GroupInfo.MemberAddMode == types.GroupMemberAddModeAdmin
-		isGroup := true
-		contact := Contact{
-			JID:     evt.JID.String(),
-			IsGroup: &isGroup,
-			Group: Group{
-				GroupName:                    &evt.GroupInfo.Name,
-				GroupTopic:                   &evt.GroupInfo.Topic,
-				GroupInfoLockedToAdmins:      &evt.GroupInfo.IsLocked,
-				GroupOnlyAdminCanMessage:     &evt.GroupInfo.IsAnnounce,
-				GroupIsParent:                &evt.GroupInfo.IsParent,
-				GroupLinkedParentJID:         &parentJID,
-				GroupIsDefaultSubGroup:       &evt.GroupInfo.IsDefaultSubGroup,
-				GroupJoinApprovalRequired:    &evt.GroupInfo.IsJoinApprovalRequired,
-				GroupCreated:                 &evt.GroupInfo.GroupCreated,
-				GroupOnlyAdminsCanAddMembers: &onlyAdminsCanAddMembers,
-			},
-		}
+		contact := parseGroupInfo(&evt.GroupInfo)
 		imageName, err := conn.pullProfilePhoto(evt.JID)
 		if err != nil {
 			log.Warn().Str("jid", contact.JID).Err(err).Msg("failed to pull profile photo")
@@ -523,22 +510,6 @@ func (conn *Connection) handleEvent(rawEvt any) {
 		if imageName != "" {
 			contact.ProfilePhoto = &imageName
 		}
-		groupParticipants := []GroupParticipant{}
-		for _, par := range evt.GroupInfo.Participants {
-			groupParticipant := GroupParticipant{
-				UserJID: par.JID.String(),
-			}
-			groupParticipant.Rank = GroupParticipantRankRegular
-			if par.IsAdmin {
-				groupParticipant.Rank = GroupParticipantRankAdmin
-			} else if par.IsSuperAdmin {
-				groupParticipant.Rank = GroupParticipantRankSuperAdmin
-			}
-			groupParticipants = append(groupParticipants, groupParticipant)
-		}
-		if len(groupParticipants) > 0 {
-			contact.GroupReplaceParticipants = groupParticipants
-		}
 		conn.Callbacks.Contact(contact)
 	case *events.GroupInfo:
 		isGroup := true
@@ -597,6 +568,9 @@ func (conn *Connection) handleEvent(rawEvt any) {
 			})
 		}
 		conn.Callbacks.Contact(contact)
+		for _, request := range parseGroupJoinRequests(evt.JID, evt.Sender, evt.Timestamp, evt.UnknownChanges) {
+			conn.handleGroupJoinRequest(request)
+		}
 	case *events.Picture:
 		contact := Contact{
 			JID: evt.JID.String(),
@@ -638,13 +612,31 @@ func (conn *Connection) handleEvent(rawEvt any) {
 	case *events.BlocklistChange:
 		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
 	case *events.NewsletterJoin:
-		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
+		newsletter := conn.parseNewsletterMetadata(evt.NewsletterMetadata)
+		t := true
+		newsletter.Following = &t
+		conn.handleNewsletter(newsletter)
 	case *events.NewsletterLeave:
-		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
+		f := false
+		role := NewsletterRole(evt.Role)
+		conn.handleNewsletter(Newsletter{
+			JID:       evt.ID.String(),
+			Following: &f,
+			Role:      &role,
+		})
 	case *events.NewsletterMuteChange:
-		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
+		muted := evt.Mute == types.NewsletterMuteOn
+		conn.handleNewsletter(Newsletter{
+			JID:   evt.ID.String(),
+			Muted: &muted,
+		})
 	case *events.NewsletterLiveUpdate:
-		log.Warn().Any("evt", evt).Type("type", evt).Msg("NOT IMPLEMENTED")
+		for _, post := range evt.Messages {
+			if post == nil {
+				continue
+			}
+			conn.Callbacks.Message(conn.parseNewsletterMessage(evt.JID, post))
+		}
 	default:
 		log.Error().Any("evt", evt).Type("type", evt).Msg("UNKNOWN EVENT TYPE")
 	}
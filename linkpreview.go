@@ -0,0 +1,275 @@
+package whatsmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// LinkPreviewFetcher fetches the preview card details for a URL, set Connection.LinkPreviews to
+// enable link previews on outgoing text messages.
+type LinkPreviewFetcher interface {
+	FetchLinkPreview(ctx context.Context, link string) (LinkPreview, error)
+}
+
+type LinkPreview struct {
+	URL         string
+	Title       string
+	Description string
+	Image       []byte // not always set
+}
+
+// HTTPLinkPreviewFetcher fetches link previews from the OpenGraph tags of the page. Only http and https URLs on
+// public addresses are fetched, for the page and its image alike, so a message can't make us request internal
+// services.
+type HTTPLinkPreviewFetcher struct {
+	Client                *http.Client // defaults to a client that times out after 10 seconds and refuses private addresses
+	MaxPageBytes          int64        // defaults to 512KB, as the tags we need are in the head of the page
+	UserAgent             string       // defaults to linkPreviewUserAgent
+	AllowPrivateAddresses bool         // also fetch from loopback, private and link-local addresses, like intranet pages
+}
+
+// linkPreviewTimeout limits how long fetching a preview may hold up sending the message.
+const linkPreviewTimeout = 10 * time.Second
+
+const linkPreviewUserAgent = "whatsmgr-linkpreview/1.0 (+https://github.com/DonovanDiamond/whatsmgr)"
+
+var (
+	// linkPreviewClient also checks the address it connects to, which catches redirects and DNS answers that
+	// change between our check and the request
+	linkPreviewClient = &http.Client{
+		Timeout: linkPreviewTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: linkPreviewTimeout,
+				Control: func(network, address string, c syscall.RawConn) error {
+					host, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+						return fmt.Errorf("refusing to connect to non-public address %s", host)
+					}
+					return nil
+				},
+			}).DialContext,
+			TLSHandshakeTimeout: linkPreviewTimeout,
+		},
+		CheckRedirect: checkLinkPreviewRedirect,
+	}
+	linkPreviewPrivateClient = &http.Client{
+		Timeout:       linkPreviewTimeout,
+		CheckRedirect: checkLinkPreviewRedirect,
+	}
+)
+
+var (
+	linkPreviewURLRegex   = regexp.MustCompile(`https?://[^\s<>"]+`)
+	linkPreviewMetaRegex  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	linkPreviewAttrRegex  = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	linkPreviewTitleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+func (f HTTPLinkPreviewFetcher) FetchLinkPreview(ctx context.Context, link string) (preview LinkPreview, err error) {
+	client := f.Client
+	if client == nil {
+		client = linkPreviewClient
+		if f.AllowPrivateAddresses {
+			client = linkPreviewPrivateClient
+		}
+	}
+	maxPageBytes := f.MaxPageBytes
+	if maxPageBytes <= 0 {
+		maxPageBytes = 512 * 1024
+	}
+
+	raw, err := f.get(ctx, client, link, maxPageBytes)
+	if err != nil {
+		return preview, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	preview, imageURL := parseOpenGraph(link, string(raw))
+	if imageURL == "" {
+		return preview, nil
+	}
+	if base, err := url.Parse(link); err == nil {
+		if ref, err := base.Parse(imageURL); err == nil {
+			imageURL = ref.String()
+		}
+	}
+	image, err := f.get(ctx, client, imageURL, 5*1024*1024)
+	if err != nil {
+		// a preview without an image is still useful
+		return preview, nil
+	}
+	preview.Image = image
+	return preview, nil
+}
+
+func (f HTTPLinkPreviewFetcher) get(ctx context.Context, client *http.Client, link string, maxBytes int64) ([]byte, error) {
+	if err := f.checkURL(ctx, link); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	userAgent := f.UserAgent
+	if userAgent == "" {
+		userAgent = linkPreviewUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+}
+
+// checkURL refuses URLs that aren't http or https, and unless private addresses are allowed, hosts that resolve to
+// one. The default client checks the address again when connecting, custom clients only get this check.
+func (f HTTPLinkPreviewFetcher) checkURL(ctx context.Context, link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if f.AllowPrivateAddresses {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("refusing to fetch from non-public address %s", addr.IP)
+		}
+	}
+	return nil
+}
+
+func checkLinkPreviewRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("unsupported redirect URL scheme %q", req.URL.Scheme)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// findLink finds the first URL in text, without the punctuation that ends the sentence around it. Closing brackets
+// are only kept when the URL opened them, like in https://en.wikipedia.org/wiki/Go_(programming_language).
+func findLink(text string) string {
+	link := linkPreviewURLRegex.FindString(text)
+	for link != "" {
+		last := link[len(link)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'*", last) >= 0:
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		case last == ']' && strings.Count(link, "[") < strings.Count(link, "]"):
+		default:
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+// parseOpenGraph reads the og: tags from a page, falling back to the standard title and description.
+func parseOpenGraph(link, page string) (preview LinkPreview, imageURL string) {
+	preview.URL = link
+	tags := map[string]string{}
+	for _, meta := range linkPreviewMetaRegex.FindAllString(page, -1) {
+		attrs := map[string]string{}
+		for _, attr := range linkPreviewAttrRegex.FindAllStringSubmatch(meta, -1) {
+			attrs[strings.ToLower(attr[1])] = attr[2] + attr[3]
+		}
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if _, exists := tags[key]; key != "" && !exists {
+			tags[key] = strings.TrimSpace(html.UnescapeString(attrs["content"]))
+		}
+	}
+
+	preview.Title = tags["og:title"]
+	if preview.Title == "" {
+		if match := linkPreviewTitleRegex.FindStringSubmatch(page); match != nil {
+			preview.Title = strings.TrimSpace(html.UnescapeString(match[1]))
+		}
+	}
+	preview.Description = tags["og:description"]
+	if preview.Description == "" {
+		preview.Description = tags["description"]
+	}
+	if u := tags["og:url"]; u != "" {
+		preview.URL = u
+	}
+	imageURL = tags["og:image"]
+	return
+}
+
+// buildTextMessage sends text as a plain conversation, unless link previews are enabled and the text contains
+// a URL that a preview could be fetched for.
+func (conn *Connection) buildTextMessage(ctx context.Context, text *string) *waE2E.Message {
+	if conn.LinkPreviews == nil || text == nil {
+		return &waE2E.Message{Conversation: text}
+	}
+	link := findLink(*text)
+	if link == "" {
+		return &waE2E.Message{Conversation: text}
+	}
+	ctx, cancel := context.WithTimeout(ctx, linkPreviewTimeout)
+	defer cancel()
+	preview, err := conn.LinkPreviews.FetchLinkPreview(ctx, link)
+	if err != nil {
+		conn.Log.Warn().Err(err).Str("url", link).Msg("failed to fetch link preview")
+		return &waE2E.Message{Conversation: text}
+	}
+	if preview.Title == "" && preview.Description == "" && len(preview.Image) == 0 {
+		return &waE2E.Message{Conversation: text}
+	}
+
+	out := &waE2E.ExtendedTextMessage{
+		Text:        text,
+		MatchedText: proto.String(link),
+		Title:       proto.String(preview.Title),
+		Description: proto.String(preview.Description),
+		PreviewType: waE2E.ExtendedTextMessage_NONE.Enum(),
+	}
+	if len(preview.Image) > 0 {
+		_, _, thumbnail, err := imageThumbnail(preview.Image)
+		if err != nil {
+			conn.Log.Warn().Err(err).Str("url", link).Msg("failed to generate link preview thumbnail")
+		} else {
+			out.JPEGThumbnail = thumbnail
+		}
+	}
+	return &waE2E.Message{ExtendedTextMessage: out}
+}
@@ -0,0 +1,125 @@
+package whatsmgr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type stubLinkPreviewFetcher struct {
+	preview LinkPreview
+	err     error
+	fetched []string
+}
+
+func (f *stubLinkPreviewFetcher) FetchLinkPreview(ctx context.Context, link string) (LinkPreview, error) {
+	f.fetched = append(f.fetched, link)
+	return f.preview, f.err
+}
+
+func TestHTTPLinkPreviewFetcher(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Fallback</title>
+			<meta property="og:title" content="Big &amp; Bold News">
+			<meta name="description" content="Plain description">
+			<META content='OpenGraph description' property='og:description' />
+			<meta property="og:image" content="/cover.png">
+		</head><body></body></html>`))
+	})
+	mux.HandleFunc("/cover.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(img.Bytes())
+	})
+	mux.HandleFunc("/local-image", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><meta property="og:title" content="Sneaky"><meta property="og:image" content="file:///etc/passwd"></head></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	fetcher := HTTPLinkPreviewFetcher{AllowPrivateAddresses: true}
+
+	preview, err := fetcher.FetchLinkPreview(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if preview.Title != "Big & Bold News" {
+		t.Errorf("Unexpected title %q", preview.Title)
+	}
+	if preview.Description != "OpenGraph description" {
+		t.Errorf("Unexpected description %q", preview.Description)
+	}
+	if !bytes.Equal(preview.Image, img.Bytes()) {
+		t.Errorf("Image was not downloaded from the relative og:image URL")
+	}
+
+	if _, err := fetcher.FetchLinkPreview(context.Background(), server.URL+"/missing"); err == nil {
+		t.Errorf("Expected error for missing page")
+	}
+
+	preview, err = fetcher.FetchLinkPreview(context.Background(), server.URL+"/local-image")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if preview.Title != "Sneaky" || len(preview.Image) != 0 {
+		t.Errorf("Expected the file:// og:image to be skipped, got %q with %d image bytes", preview.Title, len(preview.Image))
+	}
+
+	if _, err := (HTTPLinkPreviewFetcher{}).FetchLinkPreview(context.Background(), server.URL+"/article"); err == nil {
+		t.Errorf("Expected error for a page on a loopback address")
+	}
+	if _, err := (HTTPLinkPreviewFetcher{}).FetchLinkPreview(context.Background(), "http://169.254.169.254/latest/meta-data/"); err == nil {
+		t.Errorf("Expected error for a page on a link-local address")
+	}
+}
+
+func TestFindLink(t *testing.T) {
+	tests := []struct {
+		text string
+		link string
+	}{
+		{"see https://x.com.", "https://x.com"},
+		{"Is it https://example.com/a?b=1, or not?", "https://example.com/a?b=1"},
+		{"(details at https://example.com/docs)", "https://example.com/docs"},
+		{"Wow https://example.com/!", "https://example.com/"},
+		{"https://en.wikipedia.org/wiki/Go_(programming_language).", "https://en.wikipedia.org/wiki/Go_(programming_language)"},
+		{"no links here", ""},
+	}
+	for _, test := range tests {
+		if link := findLink(test.text); link != test.link {
+			t.Errorf("findLink(%q): expected %q, got %q", test.text, test.link, link)
+		}
+	}
+}
+
+func TestBuildTextMessage(t *testing.T) {
+	text := "Have a look at https://example.com/post?id=1 when you can"
+
+	out := (&Connection{}).buildTextMessage(context.Background(), &text)
+	if out.GetConversation() != text || out.ExtendedTextMessage != nil {
+		t.Errorf("Expected plain conversation when link previews are disabled, got %v", out)
+	}
+
+	fetcher := &stubLinkPreviewFetcher{preview: LinkPreview{Title: "A Post", Description: "About things"}}
+	out = (&Connection{LinkPreviews: fetcher}).buildTextMessage(context.Background(), &text)
+	if len(fetcher.fetched) != 1 || fetcher.fetched[0] != "https://example.com/post?id=1" {
+		t.Errorf("Unexpected URLs fetched: %v", fetcher.fetched)
+	}
+	x := out.GetExtendedTextMessage()
+	if x.GetText() != text || x.GetMatchedText() != "https://example.com/post?id=1" || x.GetTitle() != "A Post" || x.GetDescription() != "About things" {
+		t.Errorf("Unexpected extended text message: %v", out)
+	}
+
+	fetcher = &stubLinkPreviewFetcher{err: errors.New("offline")}
+	out = (&Connection{LinkPreviews: fetcher}).buildTextMessage(context.Background(), &text)
+	if out.GetConversation() != text {
+		t.Errorf("Expected plain conversation when the preview fails, got %v", out)
+	}
+}
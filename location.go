@@ -0,0 +1,131 @@
+package whatsmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// LiveLocation is a live location share started with StartLiveLocation, call Stop to end it.
+type LiveLocation struct {
+	Message Message // the initial live location message that was sent
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func buildLocationMessage(message Message, sequenceNumber int64, timeOffset uint32) *waE2E.Message {
+	if message.LocationIsLive != nil && *message.LocationIsLive {
+		return &waE2E.Message{
+			LiveLocationMessage: &waE2E.LiveLocationMessage{
+				DegreesLatitude:  message.LocationLat,
+				DegreesLongitude: message.LocationLon,
+				AccuracyInMeters: message.LocationAccuracyInMeters,
+				Caption:          message.LocationComment,
+				SequenceNumber:   proto.Int64(sequenceNumber),
+				TimeOffset:       proto.Uint32(timeOffset),
+			},
+		}
+	}
+	return &waE2E.Message{
+		LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  message.LocationLat,
+			DegreesLongitude: message.LocationLon,
+			AccuracyInMeters: message.LocationAccuracyInMeters,
+			Name:             message.LocationName,
+			Address:          message.LocationAddress,
+			URL:              message.LocationURL,
+			Comment:          message.LocationComment,
+			IsLive:           message.LocationIsLive,
+		},
+	}
+}
+
+// StartLiveLocation sends message as a live location, then calls nextLocation every interval and sends the position
+// it returns as an update. The share ends when nextLocation returns false or Stop is called, with a final static
+// location message at the last position. nextLocation must end the share with the stop it is given rather than
+// LiveLocation.Stop, which would wait for nextLocation to return.
+func (conn *Connection) StartLiveLocation(message Message, sendOnCallback bool, interval time.Duration, nextLocation func(stop func()) (lat, lon float64, ok bool)) (*LiveLocation, error) {
+	if message.LocationLat == nil || message.LocationLon == nil {
+		return nil, errors.New("missing message.LocationLat or message.LocationLon")
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+	chat, err := types.ParseJID(message.ChatJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ChatJID: %w", err)
+	}
+	t := true
+	message.LocationIsLive = &t
+	message, err = conn.SendMessage(message, sendOnCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	live := &LiveLocation{
+		Message: message,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(live.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		update := message
+		started := time.Now()
+		var sequenceNumber int64
+		defer func() {
+			// WhatsApp shows the share as ended once a static location is sent in its place
+			update.LocationIsLive = proto.Bool(false)
+			if _, err := conn.client.SendMessage(context.Background(), chat, buildLocationMessage(update, 0, 0)); err != nil {
+				conn.Log.Warn().Err(err).Str("chat", message.ChatJID).Msg("failed to send live location end")
+			}
+		}()
+		for {
+			select {
+			case <-live.stop:
+				return
+			case <-ticker.C:
+			}
+			lat, lon, ok := nextLocation(live.end)
+			if !ok {
+				return
+			}
+			select {
+			case <-live.stop:
+				// the share was stopped while nextLocation was running
+				return
+			default:
+			}
+			sequenceNumber++
+			update.LocationLat = &lat
+			update.LocationLon = &lon
+			out := buildLocationMessage(update, sequenceNumber, uint32(time.Since(started).Seconds()))
+			if _, err := conn.client.SendMessage(context.Background(), chat, out); err != nil {
+				conn.Log.Warn().Err(err).Str("chat", message.ChatJID).Msg("failed to send live location update")
+			}
+		}
+	}()
+	return live, nil
+}
+
+// Stop ends the share and waits for the final location message to be sent.
+func (live *LiveLocation) Stop() {
+	live.end()
+	<-live.done
+}
+
+// end ends the share without waiting, it is given to nextLocation as its stop.
+func (live *LiveLocation) end() {
+	live.stopOnce.Do(func() {
+		close(live.stop)
+	})
+}
@@ -0,0 +1,111 @@
+package whatsmgr
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBuildLocationMessage(t *testing.T) {
+	tests := []struct {
+		note           string
+		message        Message
+		sequenceNumber int64
+		timeOffset     uint32
+		expected       *waE2E.Message
+	}{
+		{
+			note: "static location",
+			message: Message{
+				LocationLat:     proto.Float64(-33.9249),
+				LocationLon:     proto.Float64(18.4241),
+				LocationName:    proto.String("Depot"),
+				LocationAddress: proto.String("1 Dock Road, Cape Town"),
+				LocationComment: proto.String("Gate 3"),
+			},
+			expected: &waE2E.Message{
+				LocationMessage: &waE2E.LocationMessage{
+					DegreesLatitude:  proto.Float64(-33.9249),
+					DegreesLongitude: proto.Float64(18.4241),
+					Name:             proto.String("Depot"),
+					Address:          proto.String("1 Dock Road, Cape Town"),
+					Comment:          proto.String("Gate 3"),
+				},
+			},
+		},
+		{
+			note: "live location update",
+			message: Message{
+				LocationLat:              proto.Float64(-33.9249),
+				LocationLon:              proto.Float64(18.4241),
+				LocationAccuracyInMeters: proto.Uint32(12),
+				LocationComment:          proto.String("On my way"),
+				LocationIsLive:           proto.Bool(true),
+			},
+			sequenceNumber: 3,
+			timeOffset:     90,
+			expected: &waE2E.Message{
+				LiveLocationMessage: &waE2E.LiveLocationMessage{
+					DegreesLatitude:  proto.Float64(-33.9249),
+					DegreesLongitude: proto.Float64(18.4241),
+					AccuracyInMeters: proto.Uint32(12),
+					Caption:          proto.String("On my way"),
+					SequenceNumber:   proto.Int64(3),
+					TimeOffset:       proto.Uint32(90),
+				},
+			},
+		},
+		{
+			note: "end of live location",
+			message: Message{
+				LocationLat:    proto.Float64(-33.9249),
+				LocationLon:    proto.Float64(18.4241),
+				LocationIsLive: proto.Bool(false),
+			},
+			expected: &waE2E.Message{
+				LocationMessage: &waE2E.LocationMessage{
+					DegreesLatitude:  proto.Float64(-33.9249),
+					DegreesLongitude: proto.Float64(18.4241),
+					IsLive:           proto.Bool(false),
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		out := buildLocationMessage(test.message, test.sequenceNumber, test.timeOffset)
+		if !proto.Equal(out, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.note, test.expected, out)
+		}
+	}
+}
+
+func TestLiveLocationStop(t *testing.T) {
+	live := &LiveLocation{stop: make(chan struct{}), done: make(chan struct{})}
+
+	// the stop given to nextLocation ends the share without waiting for it
+	live.end()
+	select {
+	case <-live.stop:
+	default:
+		t.Fatalf("Expected the share to be stopped")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		live.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatalf("Expected Stop to wait for the final location message")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(live.done)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Errorf("Expected Stop to return once the final location message was sent")
+	}
+}
@@ -0,0 +1,304 @@
+package whatsmgr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"regexp"
+	"strconv"
+)
+
+const (
+	thumbnailMaxSize = 72
+	waveformSamples  = 64
+)
+
+var (
+	pdfPageRegex      = regexp.MustCompile(`/Type\s*/Page[^s]`)
+	pdfPageCountRegex = regexp.MustCompile(`/Type\s*/Pages[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages`)
+)
+
+// pdfPageCount makes a best effort at counting the pages in a PDF without fully parsing it,
+// returns 0 if the page count could not be determined.
+func pdfPageCount(raw []byte) uint32 {
+	if !bytes.HasPrefix(raw, []byte("%PDF")) {
+		return 0
+	}
+	// the root page tree holds the total count, so the largest /Count is the one we want
+	var count uint64
+	for _, match := range pdfPageCountRegex.FindAllSubmatch(raw, -1) {
+		n := match[1]
+		if len(n) == 0 {
+			n = match[2]
+		}
+		if v, err := strconv.ParseUint(string(n), 10, 32); err == nil && v > count {
+			count = v
+		}
+	}
+	if count > 0 {
+		return uint32(count)
+	}
+	// no page tree count was found, so fall back to counting the page objects themselves
+	return uint32(len(pdfPageRegex.FindAll(raw, -1)))
+}
+
+// imageThumbnail decodes a JPEG or PNG image and returns its dimensions along with a small JPEG thumbnail.
+func imageThumbnail(raw []byte) (width, height uint32, thumbnail []byte, err error) {
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := img.Bounds()
+	width, height = uint32(bounds.Dx()), uint32(bounds.Dy())
+	if width == 0 || height == 0 {
+		return width, height, nil, errors.New("image has no pixels")
+	}
+
+	thumbWidth, thumbHeight := int(width), int(height)
+	if thumbWidth > thumbnailMaxSize || thumbHeight > thumbnailMaxSize {
+		if thumbWidth >= thumbHeight {
+			thumbHeight = max(1, thumbHeight*thumbnailMaxSize/thumbWidth)
+			thumbWidth = thumbnailMaxSize
+		} else {
+			thumbWidth = max(1, thumbWidth*thumbnailMaxSize/thumbHeight)
+			thumbHeight = thumbnailMaxSize
+		}
+	}
+
+	// box filter: each thumbnail pixel is the average of the source pixels it covers
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for ty := 0; ty < thumbHeight; ty++ {
+		y0 := bounds.Min.Y + ty*bounds.Dy()/thumbHeight
+		y1 := max(y0+1, bounds.Min.Y+(ty+1)*bounds.Dy()/thumbHeight)
+		for tx := 0; tx < thumbWidth; tx++ {
+			x0 := bounds.Min.X + tx*bounds.Dx()/thumbWidth
+			x1 := max(x0+1, bounds.Min.X+(tx+1)*bounds.Dx()/thumbWidth)
+			var r, g, b, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, _ := img.At(x, y).RGBA()
+					r, g, b, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), n+1
+				}
+			}
+			thumb.Set(tx, ty, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xffff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 75}); err != nil {
+		return width, height, nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return width, height, buf.Bytes(), nil
+}
+
+type mp4Metadata struct {
+	Seconds uint32
+	Width   uint32
+	Height  uint32
+}
+
+// mp4Info reads the duration from the movie header and the dimensions from the first video track header.
+func mp4Info(raw []byte) (info mp4Metadata, err error) {
+	moov := mp4FindBox(raw, "moov")
+	if moov == nil {
+		return info, errors.New("missing moov box")
+	}
+	if mvhd := mp4FindBox(moov, "mvhd"); len(mvhd) >= 4 {
+		var timescale, duration uint64
+		if mvhd[0] == 1 && len(mvhd) >= 32 {
+			timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+			duration = binary.BigEndian.Uint64(mvhd[24:32])
+		} else if len(mvhd) >= 20 {
+			timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+			duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+		}
+		if timescale > 0 {
+			info.Seconds = uint32((duration + timescale/2) / timescale)
+		}
+	}
+	for _, trak := range mp4Boxes(moov, "trak") {
+		hdlr := mp4FindBox(mp4FindBox(trak, "mdia"), "hdlr")
+		if len(hdlr) < 12 || string(hdlr[8:12]) != "vide" {
+			continue
+		}
+		tkhd := mp4FindBox(trak, "tkhd")
+		// width and height are the last two fields of tkhd, stored as 16.16 fixed point
+		if len(tkhd) < 84 {
+			continue
+		}
+		info.Width = binary.BigEndian.Uint32(tkhd[len(tkhd)-8:]) >> 16
+		info.Height = binary.BigEndian.Uint32(tkhd[len(tkhd)-4:]) >> 16
+		break
+	}
+	return info, nil
+}
+
+// mp4Boxes returns the contents of all boxes of the given type at the top level of data.
+func mp4Boxes(data []byte, boxType string) (boxes [][]byte) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return
+		}
+		if typ == boxType {
+			boxes = append(boxes, data[header:size])
+		}
+		data = data[size:]
+	}
+	return
+}
+
+func mp4FindBox(data []byte, boxType string) []byte {
+	boxes := mp4Boxes(data, boxType)
+	if len(boxes) == 0 {
+		return nil
+	}
+	return boxes[0]
+}
+
+type oggOpusMetadata struct {
+	Seconds  uint32
+	Waveform []byte
+}
+
+// oggOpusInfo reads the duration of an Ogg/Opus file from its final granule position and builds a waveform
+// from the size of each audio packet, as louder audio takes more bytes to encode than silence.
+func oggOpusInfo(raw []byte) (info oggOpusMetadata, err error) {
+	if len(raw) < 27 || len(raw) < 27+int(raw[26])+12 {
+		return info, errors.New("invalid ogg file")
+	}
+	head := raw[27+int(raw[26]):]
+	if string(head[0:8]) != "OpusHead" {
+		return info, errors.New("ogg file does not contain opus audio")
+	}
+	preSkip := uint64(binary.LittleEndian.Uint16(head[10:12]))
+
+	var (
+		packets []int
+		current int
+		granule uint64
+	)
+	for len(raw) >= 27 {
+		if string(raw[0:4]) != "OggS" {
+			return info, errors.New("invalid ogg page")
+		}
+		segmentCount := int(raw[26])
+		if len(raw) < 27+segmentCount {
+			return info, errors.New("truncated ogg page")
+		}
+		if pageGranule := binary.LittleEndian.Uint64(raw[6:14]); pageGranule != ^uint64(0) {
+			granule = pageGranule
+		}
+		segments := raw[27 : 27+segmentCount]
+		data := raw[27+segmentCount:]
+		offset := 0
+		for _, lacing := range segments {
+			if offset+int(lacing) > len(data) {
+				return info, errors.New("truncated ogg segment")
+			}
+			current += int(lacing)
+			offset += int(lacing)
+			if lacing < 255 {
+				packets = append(packets, current)
+				current = 0
+			}
+		}
+		raw = data[offset:]
+	}
+	// the first two packets are the OpusHead and OpusTags headers
+	if len(packets) < 3 {
+		return info, errors.New("no opus audio packets found")
+	}
+	packets = packets[2:]
+
+	if granule > preSkip {
+		info.Seconds = uint32((granule - preSkip + 24000) / 48000)
+	}
+
+	info.Waveform = make([]byte, waveformSamples)
+	averages := make([]float64, waveformSamples)
+	var peak float64
+	for i := range averages {
+		start := i * len(packets) / waveformSamples
+		end := min(len(packets), max(start+1, (i+1)*len(packets)/waveformSamples))
+		var total int
+		for _, size := range packets[start:end] {
+			total += size
+		}
+		averages[i] = float64(total) / float64(end-start)
+		peak = max(peak, averages[i])
+	}
+	if peak > 0 {
+		for i, average := range averages {
+			info.Waveform[i] = byte(average / peak * 100)
+		}
+	}
+	return info, nil
+}
+
+type webpMetadata struct {
+	Width    uint32
+	Height   uint32
+	Animated bool
+}
+
+// webpInfo reads the canvas dimensions and animation flag from the header chunks of a WebP image.
+func webpInfo(raw []byte) (info webpMetadata, err error) {
+	if len(raw) < 12 || string(raw[0:4]) != "RIFF" || string(raw[8:12]) != "WEBP" {
+		return info, errors.New("invalid webp file")
+	}
+	data := raw[12:]
+	for len(data) >= 8 {
+		chunkType := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size < 0 || 8+size > len(data) {
+			return info, errors.New("truncated webp chunk")
+		}
+		chunk := data[8 : 8+size]
+		switch chunkType {
+		case "VP8X":
+			if len(chunk) < 10 {
+				return info, errors.New("invalid VP8X chunk")
+			}
+			info.Animated = chunk[0]&0x02 != 0
+			info.Width = (uint32(chunk[4]) | uint32(chunk[5])<<8 | uint32(chunk[6])<<16) + 1
+			info.Height = (uint32(chunk[7]) | uint32(chunk[8])<<8 | uint32(chunk[9])<<16) + 1
+			return info, nil
+		case "VP8 ":
+			if len(chunk) < 10 {
+				return info, errors.New("invalid VP8 chunk")
+			}
+			info.Width = uint32(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3fff)
+			info.Height = uint32(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3fff)
+			return info, nil
+		case "VP8L":
+			if len(chunk) < 5 || chunk[0] != 0x2f {
+				return info, errors.New("invalid VP8L chunk")
+			}
+			bits := binary.LittleEndian.Uint32(chunk[1:5])
+			info.Width = (bits & 0x3fff) + 1
+			info.Height = ((bits >> 14) & 0x3fff) + 1
+			return info, nil
+		}
+		// chunks are padded to an even size
+		data = data[min(len(data), 8+size+size%2):]
+	}
+	return info, errors.New("missing webp image chunk")
+}
@@ -0,0 +1,226 @@
+package whatsmgr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestPDFPageCount(t *testing.T) {
+	tests := []struct {
+		note     string
+		raw      []byte
+		expected uint32
+	}{
+		{
+			note:     "Not a PDF",
+			raw:      []byte("hello world"),
+			expected: 0,
+		},
+		{
+			note:     "Page tree with count",
+			raw:      []byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >> endobj\n"),
+			expected: 3,
+		},
+		{
+			note:     "Page tree with count before type",
+			raw:      []byte("%PDF-1.7\n2 0 obj <</Count 12/Kids [3 0 R]/Type/Pages>> endobj\n"),
+			expected: 12,
+		},
+		{
+			note:     "Only page objects",
+			raw:      []byte("%PDF-1.4\n3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n4 0 obj << /Type/Page /Parent 2 0 R >> endobj\n"),
+			expected: 2,
+		},
+	}
+	for i, test := range tests {
+		if got := pdfPageCount(test.raw); got != test.expected {
+			t.Errorf("Test #%d (%s): expected %d pages, got %d", i, test.note, test.expected, got)
+		}
+	}
+}
+
+func TestImageThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	width, height, thumbnail, err := imageThumbnail(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if width != 200 || height != 100 {
+		t.Errorf("Expected dimensions 200x100, got %dx%d", width, height)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(thumbnail))
+	if err != nil {
+		t.Fatalf("Thumbnail is not a valid JPEG: %v", err)
+	}
+	if thumb.Bounds().Dx() != thumbnailMaxSize || thumb.Bounds().Dy() != thumbnailMaxSize/2 {
+		t.Errorf("Expected thumbnail %dx%d, got %dx%d", thumbnailMaxSize, thumbnailMaxSize/2, thumb.Bounds().Dx(), thumb.Bounds().Dy())
+	}
+
+	if _, _, _, err := imageThumbnail([]byte("not an image")); err == nil {
+		t.Errorf("Expected error for invalid image")
+	}
+}
+
+func testMP4Box(boxType string, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	box = append(box, boxType...)
+	return append(box, body...)
+}
+
+func TestMP4Info(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)  // timescale
+	binary.BigEndian.PutUint32(mvhd[16:20], 12400) // duration
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:80], 640<<16)
+	binary.BigEndian.PutUint32(tkhd[80:84], 360<<16)
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:12], "vide")
+	soundHdlr := make([]byte, 24)
+	copy(soundHdlr[8:12], "soun")
+
+	raw := bytes.Join([][]byte{
+		testMP4Box("ftyp", []byte("isom\x00\x00\x02\x00")),
+		testMP4Box("moov",
+			testMP4Box("mvhd", mvhd),
+			testMP4Box("trak", testMP4Box("tkhd", make([]byte, 84)), testMP4Box("mdia", testMP4Box("hdlr", soundHdlr))),
+			testMP4Box("trak", testMP4Box("tkhd", tkhd), testMP4Box("mdia", testMP4Box("hdlr", hdlr))),
+		),
+	}, nil)
+
+	info, err := mp4Info(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := mp4Metadata{Seconds: 12, Width: 640, Height: 360}
+	if info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+
+	if _, err := mp4Info([]byte("not an mp4")); err == nil {
+		t.Errorf("Expected error for invalid mp4")
+	}
+}
+
+func testOggPage(granule uint64, packets ...[]byte) []byte {
+	var segments, data []byte
+	for _, packet := range packets {
+		size := len(packet)
+		for size >= 255 {
+			segments = append(segments, 255)
+			size -= 255
+		}
+		segments = append(segments, byte(size))
+		data = append(data, packet...)
+	}
+	page := []byte("OggS\x00\x00")
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = append(page, make([]byte, 12)...) // serial, sequence and checksum
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, data...)
+}
+
+func TestOggOpusInfo(t *testing.T) {
+	head := []byte("OpusHead\x01\x01")
+	head = binary.LittleEndian.AppendUint16(head, 312) // pre-skip
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = append(head, 0, 0, 0)
+
+	var audio [][]byte
+	for i := range 128 {
+		size := 10
+		if i >= 64 {
+			size = 300 // spans more than one segment
+		}
+		audio = append(audio, make([]byte, size))
+	}
+	raw := bytes.Join([][]byte{
+		testOggPage(0, head),
+		testOggPage(0, []byte("OpusTags")),
+		testOggPage(48000, audio[:64]...),
+		testOggPage(312+48000*3, audio[64:]...),
+	}, nil)
+
+	info, err := oggOpusInfo(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Seconds != 3 {
+		t.Errorf("Expected 3 seconds, got %d", info.Seconds)
+	}
+	if len(info.Waveform) != waveformSamples {
+		t.Fatalf("Expected %d waveform samples, got %d", waveformSamples, len(info.Waveform))
+	}
+	if info.Waveform[0] != 3 || info.Waveform[waveformSamples-1] != 100 {
+		t.Errorf("Unexpected waveform: %v", info.Waveform)
+	}
+
+	if _, err := oggOpusInfo([]byte("not an ogg file at all, not even close")); err == nil {
+		t.Errorf("Expected error for invalid ogg")
+	}
+}
+
+func testWebPChunk(chunkType string, data []byte) []byte {
+	chunk := append([]byte(chunkType), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func testWebP(chunks ...[]byte) []byte {
+	body := append([]byte("WEBP"), bytes.Join(chunks, nil)...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func TestWebPInfo(t *testing.T) {
+	// VP8L stores width-1 and height-1 in 14 bits each
+	vp8l := []byte{0x2f}
+	vp8l = binary.LittleEndian.AppendUint32(vp8l, (512-1)|(256-1)<<14)
+
+	tests := []struct {
+		note     string
+		raw      []byte
+		expected webpMetadata
+	}{
+		{
+			note:     "Animated extended format",
+			raw:      testWebP(testWebPChunk("VP8X", []byte{0x12, 0, 0, 0, 0xff, 0x01, 0, 0xff, 0x01, 0}), testWebPChunk("ANIM", make([]byte, 6))),
+			expected: webpMetadata{Width: 512, Height: 512, Animated: true},
+		},
+		{
+			note:     "Lossless after an unknown chunk",
+			raw:      testWebP(testWebPChunk("ICCP", make([]byte, 3)), testWebPChunk("VP8L", vp8l)),
+			expected: webpMetadata{Width: 512, Height: 256},
+		},
+		{
+			note:     "Lossy",
+			raw:      testWebP(testWebPChunk("VP8 ", []byte{0, 0, 0, 0x9d, 0x01, 0x2a, 0x00, 0x02, 0x00, 0x01})),
+			expected: webpMetadata{Width: 512, Height: 256},
+		},
+	}
+	for i, test := range tests {
+		info, err := webpInfo(test.raw)
+		if err != nil {
+			t.Errorf("Test #%d (%s): unexpected error: %v", i, test.note, err)
+			continue
+		}
+		if info != test.expected {
+			t.Errorf("Test #%d (%s): expected %+v, got %+v", i, test.note, test.expected, info)
+		}
+	}
+
+	if _, err := webpInfo([]byte("RIFF\x00\x00\x00\x00WAVE")); err == nil {
+		t.Errorf("Expected error for non-webp RIFF file")
+	}
+}
@@ -1,11 +1,15 @@
 package whatsmgr
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
 )
 
 type Message struct {
@@ -23,16 +27,62 @@ type Message struct {
 	Deleted *bool `json:",omitempty"` // not always set
 	Edited  *bool `json:",omitempty"` // not always set
 
+	ServerID       *int           `json:",omitempty"` // the server ID of a channel post, used for reactions and views, not always set
+	ViewCount      *int           `json:",omitempty"` // the view count of a channel post, not always set
+	ReactionCounts map[string]int `json:",omitempty"` // the reaction counts of a channel post by emoji, not always set
+
+	Pinned             *bool   `json:",omitempty"` // pinned to the top of the chat for everyone, not always set
+	PinDurationSeconds *uint32 `json:",omitempty"` // how long the message stays pinned for, not always set
+	Kept               *bool   `json:",omitempty"` // kept from disappearing in a chat with disappearing messages, not always set
+
 	ContentBody *string `json:",omitempty"` // not always set
 
-	InfoQuotedMessageID *string `json:",omitempty"` // not always set
-	InfoParticipant     *string `json:",omitempty"` // not always set
-	InfoRemoteJID       *string `json:",omitempty"` // not always set
+	InfoQuotedMessageID   *string          `json:",omitempty"` // not always set
+	InfoQuotedMessageBody *string          `json:",omitempty"` // the text or caption of the quoted message, not always set
+	InfoQuotedMessage     *Message         `json:",omitempty"` // a snapshot of the quoted message as it was sent with the reply, with its thumbnail as the attachment, not always set
+	InfoParticipant       *string          `json:",omitempty"` // not always set
+	InfoRemoteJID         *string          `json:",omitempty"` // not always set
+	InfoMentionedJIDs     []string         `json:",omitempty"` // not always set
+	InfoIsForwarded       *bool            `json:",omitempty"` // not always set
+	InfoForwardingScore   *uint32          `json:",omitempty"` // WhatsApp shows "Forwarded many times" from 5 and up, not always set
+	InfoExternalAdReply   *ExternalAdReply `json:",omitempty"` // set when the message is a reply to an ad or has a business link card, not always set
+
+	LinkPreviewURL         *string `json:",omitempty"` // the URL in ContentBody the preview is for, not always set
+	LinkPreviewTitle       *string `json:",omitempty"` // not always set
+	LinkPreviewDescription *string `json:",omitempty"` // not always set
+
+	Selection  *Selection         `json:",omitempty"` // set when the message is a reply to a button, list or interactive message, not always set
+	Structured *StructuredMessage `json:",omitempty"` // set when the message has buttons or a list, ContentBody has a text version, not always set
+
+	Product *Product `json:",omitempty"` // not always set
+	Order   *Order   `json:",omitempty"` // not always set
+	Invoice *Invoice `json:",omitempty"` // not always set
+	Payment *Payment `json:",omitempty"` // not always set
+
+	Event *Event `json:",omitempty"` // not always set
+
+	GroupInvite           *GroupInvite           `json:",omitempty"` // not always set
+	NewsletterAdminInvite *NewsletterAdminInvite `json:",omitempty"` // not always set
+
+	StatusMention      *Status             `json:",omitempty"` // set when the sender mentioned this chat in their status, not always set
+	StatusNotification *StatusNotification `json:",omitempty"` // set when someone responded to our status, not always set
+
+	TextFont           *string `json:",omitempty"` // one of the waE2E.ExtendedTextMessage_FontType names, not always set
+	TextColorARGB      *uint32 `json:",omitempty"` // not always set
+	TextBackgroundARGB *uint32 `json:",omitempty"` // not always set
 
-	Attachments []string `json:",omitempty"` // not always set
+	Attachments        []string `json:",omitempty"` // not always set
+	AttachmentFileName *string  `json:",omitempty"` // the original file name of a document attachment, not always set
+	VoiceNote          *bool    `json:",omitempty"` // set when the audio attachment is a push-to-talk voice note, not always set
+	VideoNote          *bool    `json:",omitempty"` // the video attachment is a round push-to-video note (PTV), not always set
 
-	ContactVcard       *string `json:",omitempty"` // not always set
-	ContactDisplayName *string `json:",omitempty"` // not always set
+	StickerIsAnimated *bool        `json:",omitempty"` // not always set
+	StickerIsLottie   *bool        `json:",omitempty"` // not always set
+	StickerPack       *StickerPack `json:",omitempty"` // not always set
+
+	ContactVcard       *string         `json:",omitempty"` // not always set
+	ContactDisplayName *string         `json:",omitempty"` // not always set
+	Contacts           []SharedContact `json:",omitempty"` // when more than one contact is shared at once, not always set
 
 	LocationLat              *float64 `json:",omitempty"` // not always set
 	LocationLon              *float64 `json:",omitempty"` // not always set
@@ -48,12 +98,64 @@ type Message struct {
 	CallLogType            *CallLogType    `json:",omitempty"` // use CallLogType* constants, not always set
 	CallLogParticipantJIDs []string        `json:",omitempty"` // they have their own call outcomes that are not recorded, not always set
 
+	ScheduledCallTitle     *string            `json:",omitempty"` // not always set
+	ScheduledCallTime      *time.Time         `json:",omitempty"` // not always set
+	ScheduledCallType      *ScheduledCallType `json:",omitempty"` // use ScheduledCallType* constants, not always set
+	ScheduledCallCancelled *bool              `json:",omitempty"` // not always set
+
 	Raw any `json:",omitempty"`
 }
 
+// Selection is a tap on a button or list row, which lets bots route on SelectedID instead of the display text.
+type Selection struct {
+	Type            SelectionType `json:",omitempty"` // use SelectionType* constants
+	SelectedID      *string       `json:",omitempty"` // the ID given to the button or row when it was sent, not always set
+	DisplayText     *string       `json:",omitempty"` // the text on the button or row that was tapped, not always set
+	Description     *string       `json:",omitempty"` // the description of the list row, not always set
+	Index           *uint32       `json:",omitempty"` // the index of the template button, not always set
+	OriginMessageID *string       `json:",omitempty"` // the ID of the message with the buttons or list, not always set
+	FlowName        *string       `json:",omitempty"` // the name of the native flow for interactive responses, not always set
+	FlowParamsJSON  *string       `json:",omitempty"` // the raw native flow response params for interactive responses, not always set
+}
+
+type ExternalAdReply struct {
+	Title        *string `json:",omitempty"` // not always set
+	Body         *string `json:",omitempty"` // not always set
+	SourceType   *string `json:",omitempty"` // not always set
+	SourceID     *string `json:",omitempty"` // not always set
+	SourceURL    *string `json:",omitempty"` // not always set
+	MediaURL     *string `json:",omitempty"` // not always set
+	ThumbnailURL *string `json:",omitempty"` // not always set
+	Thumbnail    *string `json:",omitempty"` // the saved thumbnail file name in the media path, not always set
+	CtwaClid     *string `json:",omitempty"` // the click-to-WhatsApp click ID used for ad attribution, not always set
+}
+
+type SharedContact struct {
+	DisplayName *string `json:",omitempty"` // not always set
+	Vcard       string  `json:",omitempty"` // use ParseVCard to read, and VCard.String to build
+}
+
+type StickerPack struct {
+	StickerPackID string               `json:",omitempty"`
+	Name          *string              `json:",omitempty"` // not always set
+	Publisher     *string              `json:",omitempty"` // not always set
+	Description   *string              `json:",omitempty"` // not always set
+	Stickers      []StickerPackSticker `json:",omitempty"` // not always set
+}
+
+type StickerPackSticker struct {
+	FileName   string   `json:",omitempty"` // the file name of the sticker inside the sticker pack archive
+	Mimetype   *string  `json:",omitempty"` // not always set
+	Emojis     []string `json:",omitempty"` // not always set
+	IsAnimated *bool    `json:",omitempty"` // not always set
+	IsLottie   *bool    `json:",omitempty"` // not always set
+}
+
 type MessageStatus string
 type CallLogOutcome string
 type CallLogType string
+type ScheduledCallType string
+type SelectionType string
 
 const (
 	MessageStatusSent        MessageStatus = "sent"
@@ -72,17 +174,50 @@ const (
 	CallLogTypeRegular   CallLogType = "regular"
 	CallLogTypeScheduled CallLogType = "scheduled"
 	CallLogTypeVoiceChat CallLogType = "voice-chat"
+
+	ScheduledCallTypeVoice ScheduledCallType = "voice"
+	ScheduledCallTypeVideo ScheduledCallType = "video"
+
+	SelectionTypeButton         SelectionType = "button"
+	SelectionTypeList           SelectionType = "list"
+	SelectionTypeTemplateButton SelectionType = "template-button"
+	SelectionTypeInteractive    SelectionType = "interactive"
 )
 
 func (conn *Connection) handleMessage(m events.Message) {
+	if isStatus(m) {
+		conn.handleStatus(m)
+		return
+	}
 	conn.Callbacks.Message(conn.parseEventMessage(m))
 }
 
-func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
+func (conn *Connection) parseEventMessage(m events.Message) Message {
+	return conn.parseMessage(m, true)
+}
+
+// parseMessage parses a message, when download is false the media isn't downloaded and the thumbnails the message
+// carries are the attachments instead.
+func (conn *Connection) parseMessage(m events.Message, download bool) (message Message) {
 	log := conn.Log
 	if m.Message == nil {
 		return
 	}
+	if x := m.Message.GetLottieStickerMessage(); x != nil && x.Message != nil {
+		// this is normally unwrapped by whatsmeow already, but not when the message didn't come through it
+		m.Message = x.Message
+		m.IsLottieSticker = true
+		return conn.parseMessage(m, download)
+	}
+	if x := m.Message.GetGroupStatusMessage(); x.GetMessage() != nil {
+		// a status posted to a group wraps the status content, see parseStatus
+		m.Message = x.Message
+		return conn.parseMessage(m, download)
+	}
+	if x := m.Message.GetStatusAddYours(); x.GetMessage() != nil {
+		m.Message = x.Message
+		return conn.parseMessage(m, download)
+	}
 	sender := m.Info.Sender.String()
 	message = Message{
 		Timestamp: &m.Info.Timestamp,
@@ -97,12 +232,38 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		Raw:  m,
 	}
 
-	attachments, caption, err := conn.pullAttachments(m)
+	if m.Info.ServerID != 0 {
+		serverID := int(m.Info.ServerID)
+		message.ServerID = &serverID
+	}
+	if meta := m.NewsletterMeta; meta != nil && !meta.EditTS.IsZero() {
+		// channel post edits are not wrapped like normal edits, the message is the new content with the original ID
+		t := true
+		message.Edited = &t
+		if !meta.OriginalTS.IsZero() {
+			message.Timestamp = &meta.OriginalTS
+		}
+	}
+
+	pullAttachments := conn.pullAttachments
+	if !download {
+		pullAttachments = conn.pullThumbnails
+	}
+	attachments, caption, err := pullAttachments(m)
 	if err != nil {
 		conn.Log.Error().Err(err).Msg("failed to pull attachment")
 	}
 	message.ContentBody = &caption
 	message.Attachments = attachments
+	if x := m.Message.GetDocumentMessage(); x != nil && x.GetFileName() != "" {
+		message.AttachmentFileName = x.FileName
+	}
+	if m.Message.GetAudioMessage().GetPTT() {
+		message.VoiceNote = proto.Bool(true)
+	}
+	if x := m.Message.GetPtvMessage(); x != nil {
+		message.VideoNote = proto.Bool(true)
+	}
 
 	if x := m.Message.GetConversation(); x != "" {
 		message.ContentBody = &x
@@ -136,13 +297,62 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		if x.Text != nil {
 			message.ContentBody = x.Text
 		}
-		if x.ContextInfo != nil {
-			// StanzaID is the ID of the message being quoted as per https://github.com/tulir/whatsmeow/issues/88
-			if x.ContextInfo.StanzaID != nil {
-				message.InfoQuotedMessageID = x.ContextInfo.StanzaID
+		if x.GetMatchedText() != "" {
+			message.LinkPreviewURL = x.MatchedText
+		}
+		if x.GetTitle() != "" {
+			message.LinkPreviewTitle = x.Title
+		}
+		if x.GetDescription() != "" {
+			message.LinkPreviewDescription = x.Description
+		}
+		if x.Font != nil {
+			font := x.Font.String()
+			message.TextFont = &font
+		}
+		message.TextColorARGB = x.TextArgb
+		message.TextBackgroundARGB = x.BackgroundArgb
+	}
+	if x := messageContextInfo(m.Message); x != nil {
+		// StanzaID is the ID of the message being quoted as per https://github.com/tulir/whatsmeow/issues/88
+		if x.StanzaID != nil {
+			message.InfoQuotedMessageID = x.StanzaID
+			message.InfoParticipant = x.Participant
+			message.InfoRemoteJID = x.RemoteJID
+			message.InfoQuotedMessageBody = messageBody(x.QuotedMessage)
+			if x.QuotedMessage != nil {
+				quoted := conn.parseQuotedMessage(m, x)
+				message.InfoQuotedMessage = &quoted
+			}
+		}
+		if len(x.MentionedJID) > 0 {
+			message.InfoMentionedJIDs = x.MentionedJID
+		}
+		if x.GetIsForwarded() {
+			message.InfoIsForwarded = x.IsForwarded
+			message.InfoForwardingScore = x.ForwardingScore
+		}
+		if ad := x.ExternalAdReply; ad != nil {
+			reply := ExternalAdReply{
+				Title:        ad.Title,
+				Body:         ad.Body,
+				SourceType:   ad.SourceType,
+				SourceID:     ad.SourceID,
+				SourceURL:    ad.SourceURL,
+				MediaURL:     ad.MediaURL,
+				ThumbnailURL: ad.ThumbnailURL,
+				CtwaClid:     ad.CtwaClid,
+			}
+			if len(ad.Thumbnail) > 0 {
+				fileName := conn.hashFile(ad.Thumbnail) + ".jpeg"
+				path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
+				err := conn.writeFileIfNotExists(path, ad.Thumbnail)
+				if err == nil {
+					reply.Thumbnail = &fileName
+				}
 			}
+			message.InfoExternalAdReply = &reply
 		}
-		// TODO: there is a lot more that could be implemented here...
 	}
 	if x := m.Message.GetLiveLocationMessage(); x != nil {
 		message.LocationAccuracyInMeters = x.AccuracyInMeters
@@ -153,6 +363,11 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		message.LocationLon = x.DegreesLongitude
 	}
 	if x := m.Message.GetStickerMessage(); x != nil {
+		message.StickerIsAnimated = x.IsAnimated
+		message.StickerIsLottie = x.IsLottie
+		if m.IsLottieSticker {
+			message.StickerIsLottie = proto.Bool(true)
+		}
 		if len(x.PngThumbnail) > 0 {
 			fileName := conn.hashFile(x.PngThumbnail) + ".png"
 			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
@@ -163,23 +378,20 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		}
 	}
 	if x := m.Message.GetGroupInviteMessage(); x != nil {
-		invite := "**Group Invite**\n"
-		if x.GroupJID != nil {
-			invite += "\nGroupJID: " + *x.GroupJID
-		}
-		if x.InviteCode != nil {
-			invite += "\nInviteCode: " + *x.InviteCode
+		invite := GroupInvite{
+			GroupJID:   x.GetGroupJID(),
+			GroupName:  nonEmpty(x.GetGroupName()),
+			InviteCode: nonEmpty(x.GetInviteCode()),
+			InviterJID: m.Info.Sender.ToNonAD().String(),
 		}
-		if x.InviteExpiration != nil {
-			invite += "\nInviteExpiration: " + fmt.Sprint(*x.InviteExpiration)
-		}
-		if x.GroupName != nil {
-			invite += "\nGroupName: " + *x.GroupName
+		if x.GetInviteExpiration() > 0 {
+			expiry := time.Unix(x.GetInviteExpiration(), 0)
+			invite.Expiry = &expiry
 		}
+		message.GroupInvite = &invite
 		if x.Caption != nil {
-			invite += "\nCaption: " + *x.Caption
+			message.ContentBody = x.Caption
 		}
-		message.ContentBody = &invite
 		if len(x.JPEGThumbnail) > 0 {
 			fileName := conn.hashFile(x.JPEGThumbnail) + ".jpeg"
 			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
@@ -278,73 +490,188 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		}
 	}
 	if x := m.Message.GetContactsArrayMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetContactsArrayMessage()")
+		message.ContactDisplayName = x.DisplayName
+		for _, contact := range x.Contacts {
+			if contact == nil {
+				continue
+			}
+			message.Contacts = append(message.Contacts, SharedContact{
+				DisplayName: contact.DisplayName,
+				Vcard:       contact.GetVcard(),
+			})
+		}
 	}
 	if x := m.Message.GetHighlyStructuredMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetHighlyStructuredMessage()")
+		structured := parseHighlyStructuredMessage(x)
+		message.Structured = &structured
+		text := structured.Text()
+		message.ContentBody = &text
 	}
 	if x := m.Message.GetFastRatchetKeySenderKeyDistributionMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetFastRatchetKeySenderKeyDistributionMessage()")
 	}
 	if x := m.Message.GetSendPaymentMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetSendPaymentMessage()")
+		payment := parseSendPaymentMessage(x)
+		message.Payment = &payment
+		if payment.Note != nil {
+			message.ContentBody = payment.Note
+		}
 	}
 	if x := m.Message.GetRequestPaymentMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetRequestPaymentMessage()")
+		payment := parseRequestPaymentMessage(x, message.MessageID, message.SenderJID)
+		message.Payment = &payment
+		if payment.Note != nil {
+			message.ContentBody = payment.Note
+		}
 	}
 	if x := m.Message.GetDeclinePaymentRequestMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetDeclinePaymentRequestMessage()")
+		// like an edit, this only updates the status of the original request message
+		requestID := x.GetKey().GetID()
+		return Message{
+			MessageID: requestID,
+			ChatJID:   message.ChatJID,
+			Payment: &Payment{
+				Status:           PaymentStatusDeclined,
+				RequestMessageID: &requestID,
+			},
+			Raw: m,
+		}
 	}
 	if x := m.Message.GetCancelPaymentRequestMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetCancelPaymentRequestMessage()")
+		// like an edit, this only updates the status of the original request message
+		requestID := x.GetKey().GetID()
+		return Message{
+			MessageID: requestID,
+			ChatJID:   message.ChatJID,
+			Payment: &Payment{
+				Status:           PaymentStatusCancelled,
+				RequestMessageID: &requestID,
+			},
+			Raw: m,
+		}
 	}
 	if x := m.Message.GetTemplateMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetTemplateMessage()")
+		structured := parseTemplateMessage(x)
+		message.Structured = &structured
+		text := structured.Text()
+		message.ContentBody = &text
 	}
 	if x := m.Message.GetTemplateButtonReplyMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetTemplateButtonReplyMessage()")
+		message.Selection = &Selection{
+			Type:            SelectionTypeTemplateButton,
+			SelectedID:      x.SelectedID,
+			DisplayText:     x.SelectedDisplayText,
+			Index:           x.SelectedIndex,
+			OriginMessageID: nonEmpty(x.GetContextInfo().GetStanzaID()),
+		}
+		message.ContentBody = x.SelectedDisplayText
 	}
 	if x := m.Message.GetProductMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetProductMessage()")
+		product := parseProductMessage(x)
+		message.Product = &product
+		if x.Body != nil {
+			message.ContentBody = x.Body
+		} else if product.Title != nil {
+			message.ContentBody = product.Title
+		}
 	}
 	if x := m.Message.GetDeviceSentMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetDeviceSentMessage()")
 	}
 	if x := m.Message.GetListMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetListMessage()")
+		structured := parseListMessage(x)
+		message.Structured = &structured
+		text := structured.Text()
+		message.ContentBody = &text
 	}
 	if x := m.Message.GetViewOnceMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetViewOnceMessage()")
 	}
 	if x := m.Message.GetOrderMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetOrderMessage()")
+		order := parseOrderMessage(x)
+		message.Order = &order
+		if x.Message != nil {
+			message.ContentBody = x.Message
+		}
+		if len(x.Thumbnail) > 0 {
+			fileName := conn.hashFile(x.Thumbnail) + ".jpeg"
+			path := fmt.Sprintf("%s/%s", conn.MediaPath, fileName)
+			err := conn.writeFileIfNotExists(path, x.Thumbnail)
+			if err == nil {
+				message.Attachments = append(message.Attachments, fileName)
+			}
+		}
 	}
 	if x := m.Message.GetListResponseMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetListResponseMessage()")
+		message.Selection = &Selection{
+			Type:            SelectionTypeList,
+			SelectedID:      nonEmpty(x.GetSingleSelectReply().GetSelectedRowID()),
+			DisplayText:     x.Title,
+			Description:     x.Description,
+			OriginMessageID: nonEmpty(x.GetContextInfo().GetStanzaID()),
+		}
+		message.ContentBody = x.Title
 	}
 	if x := m.Message.GetEphemeralMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetEphemeralMessage()")
 	}
 	if x := m.Message.GetInvoiceMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetInvoiceMessage()")
+		invoice := parseInvoiceMessage(x)
+		message.Invoice = &invoice
+		if x.Note != nil {
+			message.ContentBody = x.Note
+		}
 	}
 	if x := m.Message.GetButtonsMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetButtonsMessage()")
+		structured := parseButtonsMessage(x)
+		message.Structured = &structured
+		text := structured.Text()
+		message.ContentBody = &text
 	}
 	if x := m.Message.GetButtonsResponseMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetButtonsResponseMessage()")
+		message.Selection = &Selection{
+			Type:            SelectionTypeButton,
+			SelectedID:      x.SelectedButtonID,
+			OriginMessageID: nonEmpty(x.GetContextInfo().GetStanzaID()),
+		}
+		if text := x.GetSelectedDisplayText(); text != "" {
+			message.Selection.DisplayText = &text
+			message.ContentBody = &text
+		}
 	}
 	if x := m.Message.GetPaymentInviteMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPaymentInviteMessage()")
+		payment := parsePaymentInviteMessage(x)
+		message.Payment = &payment
 	}
 	if x := m.Message.GetInteractiveMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetInteractiveMessage()")
+		structured := parseInteractiveMessage(x)
+		message.Structured = &structured
+		text := structured.Text()
+		message.ContentBody = &text
 	}
 	if x := m.Message.GetStickerSyncRmrMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetStickerSyncRmrMessage()")
 	}
 	if x := m.Message.GetInteractiveResponseMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetInteractiveResponseMessage()")
+		message.Selection = &Selection{
+			Type:            SelectionTypeInteractive,
+			DisplayText:     nonEmpty(x.GetBody().GetText()),
+			OriginMessageID: nonEmpty(x.GetContextInfo().GetStanzaID()),
+		}
+		if flow := x.GetNativeFlowResponseMessage(); flow != nil {
+			message.Selection.FlowName = flow.Name
+			message.Selection.FlowParamsJSON = flow.ParamsJSON
+			// quick reply buttons put the ID of the button in the params
+			var params struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal([]byte(flow.GetParamsJSON()), &params); err == nil && params.ID != "" {
+				message.Selection.SelectedID = &params.ID
+			}
+		}
+		if x.GetBody().GetText() != "" {
+			message.ContentBody = x.Body.Text
+		}
 	}
 	if x := m.Message.GetPollCreationMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollCreationMessage()")
@@ -353,7 +680,14 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollUpdateMessage()")
 	}
 	if x := m.Message.GetKeepInChatMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetKeepInChatMessage()")
+		// like an edit, this only updates the original message
+		kept := x.GetKeepType() == waE2E.KeepType_KEEP_FOR_ALL
+		return Message{
+			MessageID: x.GetKey().GetID(),
+			ChatJID:   message.ChatJID,
+			Kept:      &kept,
+			Raw:       m,
+		}
 	}
 	if x := m.Message.GetDocumentWithCaptionMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetDocumentWithCaptionMessage()")
@@ -374,22 +708,60 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollCreationMessageV2()")
 	}
 	if x := m.Message.GetScheduledCallCreationMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetScheduledCallCreationMessage()")
+		message.ScheduledCallTitle = x.Title
+		if x.Title != nil {
+			message.ContentBody = x.Title
+		}
+		if x.GetScheduledTimestampMS() > 0 {
+			scheduled := time.UnixMilli(x.GetScheduledTimestampMS())
+			message.ScheduledCallTime = &scheduled
+		}
+		var callType ScheduledCallType
+		switch x.GetCallType() {
+		case waE2E.ScheduledCallCreationMessage_VOICE:
+			callType = ScheduledCallTypeVoice
+		case waE2E.ScheduledCallCreationMessage_VIDEO:
+			callType = ScheduledCallTypeVideo
+		}
+		if callType != "" {
+			message.ScheduledCallType = &callType
+		}
+		message.ScheduledCallCancelled = proto.Bool(false)
 	}
 	if x := m.Message.GetGroupMentionedMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetGroupMentionedMessage()")
 	}
 	if x := m.Message.GetPinInChatMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPinInChatMessage()")
+		// like an edit, this only updates the original message
+		pinned := x.GetType() == waE2E.PinInChatMessage_PIN_FOR_ALL
+		update := Message{
+			MessageID: x.GetKey().GetID(),
+			ChatJID:   message.ChatJID,
+			Pinned:    &pinned,
+			Raw:       m,
+		}
+		if pinned && m.Message.GetMessageContextInfo() != nil {
+			update.PinDurationSeconds = m.Message.GetMessageContextInfo().MessageAddOnDurationInSecs
+		}
+		return update
 	}
 	if x := m.Message.GetPollCreationMessageV3(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollCreationMessageV3()")
 	}
 	if x := m.Message.GetScheduledCallEditMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetScheduledCallEditMessage()")
-	}
-	if x := m.Message.GetPtvMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPtvMessage()")
+		// like an edit, this only updates the original scheduled call message, cancelling is the only edit there is
+		if x.GetEditType() != waE2E.ScheduledCallEditMessage_CANCEL {
+			log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetScheduledCallEditMessage() edit type")
+		} else {
+			t := true
+			return Message{
+				MessageID:              x.GetKey().GetID(),
+				ChatJID:                message.ChatJID,
+				Edited:                 &t,
+				ScheduledCallCancelled: &t,
+				Raw:                    m,
+			}
+		}
 	}
 	if x := m.Message.GetBotInvokeMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetBotInvokeMessage()")
@@ -403,38 +775,132 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 	if x := m.Message.GetBcallMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetBcallMessage()")
 	}
-	if x := m.Message.GetLottieStickerMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetLottieStickerMessage()")
-	}
 	if x := m.Message.GetEventMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetEventMessage()")
+		event := parseEvent(x)
+		message.Event = &event
+		if event.Name != nil {
+			message.ContentBody = event.Name
+		}
 	}
 	if x := m.Message.GetEncEventResponseMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetEncEventResponseMessage()")
+		// like an edit, this only adds the response to the original event message
+		update := Message{
+			MessageID: x.GetEventCreationMessageKey().GetID(),
+			ChatJID:   message.ChatJID,
+			Raw:       m,
+		}
+		response, err := conn.decryptEventResponse(m)
+		if err != nil {
+			// the response can't be shown, but it must not turn up as a new message either
+			log.Error().Err(err).Msg("failed to decrypt event response")
+			return update
+		}
+		update.Event = &Event{
+			Responses: []EventResponse{response},
+		}
+		return update
 	}
 	if x := m.Message.GetCommentMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetCommentMessage()")
 	}
 	if x := m.Message.GetNewsletterAdminInviteMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetNewsletterAdminInviteMessage()")
+		invite := NewsletterAdminInvite{
+			NewsletterJID:  x.GetNewsletterJID(),
+			NewsletterName: x.NewsletterName,
+		}
+		if x.GetInviteExpiration() > 0 {
+			expiry := time.Unix(x.GetInviteExpiration(), 0)
+			invite.Expiry = &expiry
+		}
+		message.NewsletterAdminInvite = &invite
+		if x.Caption != nil {
+			message.ContentBody = x.Caption
+		}
+		fileName, err := conn.saveAttachment(x.JPEGThumbnail, "", ".jpeg")
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to save newsletter admin invite thumbnail")
+		} else if fileName != "" {
+			message.Attachments = append(message.Attachments, fileName)
+		}
 	}
 	if x := m.Message.GetPlaceholderMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPlaceholderMessage()")
 	}
 	if x := m.Message.GetSecretEncryptedMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetSecretEncryptedMessage()")
+		if x.GetSecretEncType() != waE2E.SecretEncryptedMessage_EVENT_EDIT {
+			log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetSecretEncryptedMessage()")
+		} else if decrypted, err := conn.client.DecryptSecretEncryptedMessage(context.Background(), &m); err != nil {
+			log.Error().Err(err).Msg("failed to decrypt event edit")
+		} else if decrypted.GetEventMessage() != nil {
+			// like an edit, this updates the original event message, cancelling an event is also an edit
+			event := parseEvent(decrypted.GetEventMessage())
+			t := true
+			return Message{
+				MessageID: x.GetTargetMessageKey().GetID(),
+				ChatJID:   message.ChatJID,
+				Edited:    &t,
+				Event:     &event,
+				Raw:       m,
+			}
+		}
 	}
 	if x := m.Message.GetAlbumMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetAlbumMessage()")
 	}
 	if x := m.Message.GetEventCoverImage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetEventCoverImage()")
+		// the cover image is sent after the event, associated with it through the message context info
+		update := Message{
+			MessageID: m.Message.GetMessageContextInfo().GetMessageAssociation().GetParentMessageKey().GetID(),
+			ChatJID:   message.ChatJID,
+			Raw:       m,
+		}
+		cover := m
+		cover.Message = x.GetMessage()
+		attachments, _, err := pullAttachments(cover)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to pull event cover image")
+		} else if update.MessageID == "" {
+			log.Warn().Msg("event cover image without the event it belongs to")
+		}
+		if update.MessageID == "" || len(attachments) == 0 {
+			// the cover can't be shown, but it must not turn up as a new message either
+			return update
+		}
+		update.Attachments = attachments
+		update.Event = &Event{
+			CoverImage: &attachments[0],
+		}
+		return update
 	}
 	if x := m.Message.GetStickerPackMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetStickerPackMessage()")
+		pack := StickerPack{
+			StickerPackID: x.GetStickerPackID(),
+			Name:          x.Name,
+			Publisher:     x.Publisher,
+			Description:   x.PackDescription,
+		}
+		for _, sticker := range x.Stickers {
+			if sticker == nil {
+				continue
+			}
+			pack.Stickers = append(pack.Stickers, StickerPackSticker{
+				FileName:   sticker.GetFileName(),
+				Mimetype:   sticker.Mimetype,
+				Emojis:     sticker.Emojis,
+				IsAnimated: sticker.IsAnimated,
+				IsLottie:   sticker.IsLottie,
+			})
+		}
+		message.StickerPack = &pack
+		if x.Caption != nil {
+			message.ContentBody = x.Caption
+		}
 	}
-	if x := m.Message.GetStatusMentionMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetStatusMentionMessage()")
+	if x := m.Message.GetStatusMentionMessage(); x.GetMessage() != nil {
+		mention := conn.parseStatusMention(m, x.Message)
+		message.StatusMention = &mention
+		message.ContentBody = mention.Caption
+		message.Attachments = mention.Attachments
 	}
 	if x := m.Message.GetPollResultSnapshotMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollResultSnapshotMessage()")
@@ -445,8 +911,11 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 	if x := m.Message.GetAssociatedChildMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetAssociatedChildMessage()")
 	}
-	if x := m.Message.GetGroupStatusMentionMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetGroupStatusMentionMessage()")
+	if x := m.Message.GetGroupStatusMentionMessage(); x.GetMessage() != nil {
+		mention := conn.parseStatusMention(m, x.Message)
+		message.StatusMention = &mention
+		message.ContentBody = mention.Caption
+		message.Attachments = mention.Attachments
 	}
 	if x := m.Message.GetPollCreationMessageV4(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollCreationMessageV4()")
@@ -454,17 +923,12 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 	if x := m.Message.GetPollCreationMessageV5(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetPollCreationMessageV5()")
 	}
-	if x := m.Message.GetStatusAddYours(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetStatusAddYours()")
-	}
-	if x := m.Message.GetGroupStatusMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetGroupStatusMessage()")
-	}
 	if x := m.Message.GetRichResponseMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetRichResponseMessage()")
 	}
 	if x := m.Message.GetStatusNotificationMessage(); x != nil {
-		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetStatusNotificationMessage()")
+		notification := parseStatusNotificationMessage(x)
+		message.StatusNotification = &notification
 	}
 	if x := m.Message.GetLimitSharingMessage(); x != nil {
 		log.Warn().Any("x", x).Msg("NOT IMPLEMENTED: Message.GetLimitSharingMessage()")
@@ -478,3 +942,147 @@ func (conn *Connection) parseEventMessage(m events.Message) (message Message) {
 
 	return
 }
+
+// parseQuotedMessage parses the copy of the quoted message that is sent along with a reply, so that it can
+// be shown even if the original message was never received. The quoted media is not downloaded again, its
+// thumbnail is the attachment instead.
+func (conn *Connection) parseQuotedMessage(m events.Message, contextInfo *waE2E.ContextInfo) Message {
+	quotedMessage := proto.Clone(contextInfo.GetQuotedMessage()).(*waE2E.Message)
+	// only go one level deep, a quote inside a quote is not shown by WhatsApp either
+	if nested := messageContextInfo(quotedMessage); nested != nil {
+		nested.QuotedMessage = nil
+	}
+	quoted := events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat: m.Info.Chat,
+			},
+			ID: contextInfo.GetStanzaID(),
+		},
+		Message:    quotedMessage,
+		RawMessage: quotedMessage,
+	}
+	if contextInfo.GetRemoteJID() != "" {
+		if jid, err := types.ParseJID(contextInfo.GetRemoteJID()); err == nil {
+			quoted.Info.Chat = jid
+		}
+	}
+	if contextInfo.GetParticipant() != "" {
+		if jid, err := types.ParseJID(contextInfo.GetParticipant()); err == nil {
+			quoted.Info.Sender = jid
+		}
+	}
+
+	message := conn.parseMessage(quoted, false)
+	// quoted pins, edits and the like are shown as themselves, not as an update of the message they changed
+	message.MessageID = quoted.Info.ID
+	message.ChatJID = quoted.Info.Chat.String()
+	message.SenderJID = nonEmpty(contextInfo.GetParticipant())
+	// we only know what was sent with the reply, not when or how the quoted message was sent
+	message.Timestamp = nil
+	message.IsFromMe = nil
+	message.Type = quotedMessageType(quotedMessage)
+	message.Raw = nil
+	return message
+}
+
+// quotedMessageType returns the message type whatsmeow would have given the quoted message when it was received,
+// for the types a quote can show.
+func quotedMessageType(m *waE2E.Message) *string {
+	var messageType string
+	switch {
+	case m.GetConversation() != "" || m.GetExtendedTextMessage() != nil:
+		messageType = "text"
+	case m.GetImageMessage() != nil || m.GetVideoMessage() != nil || m.GetPtvMessage() != nil ||
+		m.GetAudioMessage() != nil || m.GetDocumentMessage() != nil || m.GetStickerMessage() != nil ||
+		m.GetLocationMessage() != nil || m.GetLiveLocationMessage() != nil ||
+		m.GetContactMessage() != nil || m.GetContactsArrayMessage() != nil:
+		messageType = "media"
+	case m.GetPollCreationMessage() != nil || m.GetPollCreationMessageV2() != nil || m.GetPollCreationMessageV3() != nil:
+		messageType = "poll"
+	default:
+		return nil
+	}
+	return &messageType
+}
+
+// messageContextInfo returns the context info of whichever message type is set, the context info holds
+// the quoted message, mentions and forwarding details.
+func messageContextInfo(m *waE2E.Message) *waE2E.ContextInfo {
+	switch {
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetContextInfo()
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetContextInfo()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetContextInfo()
+	case m.GetPtvMessage() != nil:
+		return m.GetPtvMessage().GetContextInfo()
+	case m.GetAudioMessage() != nil:
+		return m.GetAudioMessage().GetContextInfo()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetContextInfo()
+	case m.GetStickerMessage() != nil:
+		return m.GetStickerMessage().GetContextInfo()
+	case m.GetContactMessage() != nil:
+		return m.GetContactMessage().GetContextInfo()
+	case m.GetContactsArrayMessage() != nil:
+		return m.GetContactsArrayMessage().GetContextInfo()
+	case m.GetLocationMessage() != nil:
+		return m.GetLocationMessage().GetContextInfo()
+	case m.GetLiveLocationMessage() != nil:
+		return m.GetLiveLocationMessage().GetContextInfo()
+	case m.GetProductMessage() != nil:
+		return m.GetProductMessage().GetContextInfo()
+	case m.GetOrderMessage() != nil:
+		return m.GetOrderMessage().GetContextInfo()
+	case m.GetButtonsMessage() != nil:
+		return m.GetButtonsMessage().GetContextInfo()
+	case m.GetListMessage() != nil:
+		return m.GetListMessage().GetContextInfo()
+	case m.GetTemplateMessage() != nil:
+		return m.GetTemplateMessage().GetContextInfo()
+	case m.GetInteractiveMessage() != nil:
+		return m.GetInteractiveMessage().GetContextInfo()
+	case m.GetButtonsResponseMessage() != nil:
+		return m.GetButtonsResponseMessage().GetContextInfo()
+	case m.GetListResponseMessage() != nil:
+		return m.GetListResponseMessage().GetContextInfo()
+	case m.GetTemplateButtonReplyMessage() != nil:
+		return m.GetTemplateButtonReplyMessage().GetContextInfo()
+	case m.GetInteractiveResponseMessage() != nil:
+		return m.GetInteractiveResponseMessage().GetContextInfo()
+	case m.GetEventMessage() != nil:
+		return m.GetEventMessage().GetContextInfo()
+	case m.GetNewsletterAdminInviteMessage() != nil:
+		return m.GetNewsletterAdminInviteMessage().GetContextInfo()
+	}
+	return nil
+}
+
+// messageBody returns the text or caption of a message, used for quoted messages and payment notes.
+func messageBody(m *waE2E.Message) *string {
+	switch {
+	case m == nil:
+		return nil
+	case m.Conversation != nil:
+		return m.Conversation
+	case m.GetExtendedTextMessage().GetText() != "":
+		return m.ExtendedTextMessage.Text
+	case m.GetImageMessage().GetCaption() != "":
+		return m.ImageMessage.Caption
+	case m.GetVideoMessage().GetCaption() != "":
+		return m.VideoMessage.Caption
+	case m.GetDocumentMessage().GetCaption() != "":
+		return m.DocumentMessage.Caption
+	}
+	return nil
+}
+
+// nonEmpty returns nil for an empty string, for use with the proto getters that return "" when not set.
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
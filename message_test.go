@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
 )
 
 var testMessages = []struct {
@@ -20,7 +24,97 @@ var testMessages = []struct {
 	{
 		note:                "Incoming text message quote",
 		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"09876543210987654321098765432109","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Donovan Diamond","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T11:36:36Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"extendedTextMessage":{"contextInfo":{"participant":"123456789@s.whatsapp.net","quotedMessage":{"conversation":"Hello world!"},"stanzaID":"12345678901234567890123456789012"},"inviteLinkGroupTypeV2":0,"previewType":0,"text":"Well"},"messageContextInfo":{"deviceListMetadata":{"recipientKeyHash":"U29tZUJhc2U2NEhhc2g=","recipientTimestamp":1745938978,"senderTimestamp":1743814938},"deviceListMetadataVersion":2,"messageSecret":"U29tZUJhc2U2NEhhc2g="}},"NewsletterMeta":null,"RawMessage":{"extendedTextMessage":{"contextInfo":{"participant":"123456789@s.whatsapp.net","quotedMessage":{"conversation":"Hello world!"},"stanzaID":"12345678901234567890123456789012"},"inviteLinkGroupTypeV2":0,"previewType":0,"text":"Well"},"messageContextInfo":{"deviceListMetadata":{"recipientKeyHash":"U29tZUJhc2U2NEhhc2g=","recipientTimestamp":1745938978,"senderTimestamp":1743814938},"deviceListMetadataVersion":2,"messageSecret":"U29tZUJhc2U2NEhhc2g="}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
-		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T11:36:36Z","MessageID":"09876543210987654321098765432109","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Well","InfoQuotedMessageID":"12345678901234567890123456789012"}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T11:36:36Z","MessageID":"09876543210987654321098765432109","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Well","InfoQuotedMessageID":"12345678901234567890123456789012","InfoQuotedMessageBody":"Hello world!","InfoQuotedMessage":{"MessageID":"12345678901234567890123456789012","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","Type":"text","ContentBody":"Hello world!"},"InfoParticipant":"123456789@s.whatsapp.net"}`),
+	},
+	{
+		note:                "Incoming contacts array",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"11112222333344445555666677778888","IsFromMe":false,"IsGroup":false,"MediaType":"contact_array","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T12:00:00Z","Type":"media","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"contactsArrayMessage":{"contacts":[{"displayName":"Joe Blow","vcard":"BEGIN:VCARD\\nVERSION:3.0\\nN:Blow;Joe;;;\\nFN:Joe Blow\\nTEL;type=CELL;type=VOICE;waid=27821234567:+27 82 123 4567\\nEND:VCARD"},{"displayName":"Jane Doe","vcard":"BEGIN:VCARD\\nVERSION:3.0\\nFN:Jane Doe\\nEND:VCARD"}],"displayName":"2 contacts"}},"NewsletterMeta":null,"RawMessage":{"contactsArrayMessage":{"contacts":[{"displayName":"Joe Blow","vcard":"BEGIN:VCARD\\nVERSION:3.0\\nN:Blow;Joe;;;\\nFN:Joe Blow\\nTEL;type=CELL;type=VOICE;waid=27821234567:+27 82 123 4567\\nEND:VCARD"},{"displayName":"Jane Doe","vcard":"BEGIN:VCARD\\nVERSION:3.0\\nFN:Jane Doe\\nEND:VCARD"}],"displayName":"2 contacts"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T12:00:00Z","MessageID":"11112222333344445555666677778888","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"media","ContentBody":"","ContactDisplayName":"2 contacts","Contacts":[{"DisplayName":"Joe Blow","Vcard":"BEGIN:VCARD\\nVERSION:3.0\\nN:Blow;Joe;;;\\nFN:Joe Blow\\nTEL;type=CELL;type=VOICE;waid=27821234567:+27 82 123 4567\\nEND:VCARD"},{"DisplayName":"Jane Doe","Vcard":"BEGIN:VCARD\\nVERSION:3.0\\nFN:Jane Doe\\nEND:VCARD"}]}`),
+	},
+	{
+		note:                "Incoming forwarded link preview from an ad",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"22223333444455556666777788889999","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T12:30:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"extendedTextMessage":{"contextInfo":{"externalAdReply":{"body":"Ask us about our specials","mediaType":1,"sourceID":"1234567890","sourceType":"ad","sourceURL":"https://fb.me/abcdef","title":"Summer Sale","ctwaClid":"Aff-abcdef"},"forwardingScore":6,"isForwarded":true},"description":"All the news","matchedText":"https://example.com/news","previewType":0,"text":"Look https://example.com/news","title":"Example News"}},"NewsletterMeta":null,"RawMessage":{"extendedTextMessage":{"contextInfo":{"externalAdReply":{"body":"Ask us about our specials","mediaType":1,"sourceID":"1234567890","sourceType":"ad","sourceURL":"https://fb.me/abcdef","title":"Summer Sale","ctwaClid":"Aff-abcdef"},"forwardingScore":6,"isForwarded":true},"description":"All the news","matchedText":"https://example.com/news","previewType":0,"text":"Look https://example.com/news","title":"Example News"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T12:30:00Z","MessageID":"22223333444455556666777788889999","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Look https://example.com/news","InfoIsForwarded":true,"InfoForwardingScore":6,"InfoExternalAdReply":{"Title":"Summer Sale","Body":"Ask us about our specials","SourceType":"ad","SourceID":"1234567890","SourceURL":"https://fb.me/abcdef","CtwaClid":"Aff-abcdef"},"LinkPreviewURL":"https://example.com/news","LinkPreviewTitle":"Example News","LinkPreviewDescription":"All the news"}`),
+	},
+	{
+		note:                "Incoming list response",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"33334444555566667777888899990000","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T13:00:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"listResponseMessage":{"contextInfo":{"participant":"987654321@s.whatsapp.net","quotedMessage":{"conversation":"Pick a delivery slot"},"stanzaID":"ABCDEF0123456789ABCDEF0123456789"},"description":"Between 12:00 and 14:00","listType":1,"singleSelectReply":{"selectedRowID":"slot-midday"},"title":"Midday"}},"NewsletterMeta":null,"RawMessage":{"listResponseMessage":{"contextInfo":{"participant":"987654321@s.whatsapp.net","quotedMessage":{"conversation":"Pick a delivery slot"},"stanzaID":"ABCDEF0123456789ABCDEF0123456789"},"description":"Between 12:00 and 14:00","listType":1,"singleSelectReply":{"selectedRowID":"slot-midday"},"title":"Midday"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T13:00:00Z","MessageID":"33334444555566667777888899990000","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Midday","InfoQuotedMessageID":"ABCDEF0123456789ABCDEF0123456789","InfoQuotedMessageBody":"Pick a delivery slot","InfoQuotedMessage":{"MessageID":"ABCDEF0123456789ABCDEF0123456789","ChatJID":"123456789@s.whatsapp.net","SenderJID":"987654321@s.whatsapp.net","Type":"text","ContentBody":"Pick a delivery slot"},"InfoParticipant":"987654321@s.whatsapp.net","Selection":{"Type":"list","SelectedID":"slot-midday","DisplayText":"Midday","Description":"Between 12:00 and 14:00","OriginMessageID":"ABCDEF0123456789ABCDEF0123456789"}}`),
+	},
+	{
+		note:                "Incoming list message",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"44445555666677778888999900001111","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T12:45:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"listMessage":{"buttonText":"View slots","description":"When should we deliver?","footerText":"Reply STOP to opt out","listType":1,"sections":[{"rows":[{"description":"Between 08:00 and 10:00","rowID":"slot-morning","title":"Morning"},{"rowID":"slot-midday","title":"Midday"}],"title":"Tomorrow"}],"title":"Delivery"}},"NewsletterMeta":null,"RawMessage":{"listMessage":{"buttonText":"View slots","description":"When should we deliver?","footerText":"Reply STOP to opt out","listType":1,"sections":[{"rows":[{"description":"Between 08:00 and 10:00","rowID":"slot-morning","title":"Morning"},{"rowID":"slot-midday","title":"Midday"}],"title":"Tomorrow"}],"title":"Delivery"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T12:45:00Z","MessageID":"44445555666677778888999900001111","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Delivery\n\nWhen should we deliver?\n\nReply STOP to opt out\n\nTomorrow:\n- Morning: Between 08:00 and 10:00\n- Midday","Structured":{"Type":"list","Header":"Delivery","Body":"When should we deliver?","Footer":"Reply STOP to opt out","ListButton":"View slots","Sections":[{"Title":"Tomorrow","Rows":[{"ID":"slot-morning","Title":"Morning","Description":"Between 08:00 and 10:00"},{"ID":"slot-midday","Title":"Midday"}]}]}}`),
+	},
+	{
+		note:                "Incoming catalog order",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"55556666777788889999000011112222","IsFromMe":false,"IsGroup":false,"MediaType":"order","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T14:00:00Z","Type":"media","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"orderMessage":{"itemCount":3,"message":"Please deliver after 5pm","orderID":"1234567890123456","orderTitle":"Joe's order","sellerJID":"987654321@s.whatsapp.net","status":1,"surface":1,"token":"T3JkZXJUb2tlbg==","totalAmount1000":149990,"totalCurrencyCode":"ZAR"}},"NewsletterMeta":null,"RawMessage":{"orderMessage":{"itemCount":3,"message":"Please deliver after 5pm","orderID":"1234567890123456","orderTitle":"Joe's order","sellerJID":"987654321@s.whatsapp.net","status":1,"surface":1,"token":"T3JkZXJUb2tlbg==","totalAmount1000":149990,"totalCurrencyCode":"ZAR"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T14:00:00Z","MessageID":"55556666777788889999000011112222","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"media","ContentBody":"Please deliver after 5pm","Order":{"OrderID":"1234567890123456","Title":"Joe's order","ItemCount":3,"Status":"inquiry","TotalAmount1000":149990,"TotalCurrencyCode":"ZAR","SellerJID":"987654321@s.whatsapp.net","Token":"T3JkZXJUb2tlbg=="}}`),
+	},
+	{
+		note:                "Incoming payment request",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"66667777888899990000111122223333","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T15:00:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"requestPaymentMessage":{"amount":{"currencyCode":"INR","offset":2,"value":25050},"currencyCodeIso4217":"INR","noteMessage":{"extendedTextMessage":{"text":"Dinner on Friday"}},"requestFrom":"987654321@s.whatsapp.net"}},"NewsletterMeta":null,"RawMessage":{"requestPaymentMessage":{"amount":{"currencyCode":"INR","offset":2,"value":25050},"currencyCodeIso4217":"INR","noteMessage":{"extendedTextMessage":{"text":"Dinner on Friday"}},"requestFrom":"987654321@s.whatsapp.net"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T15:00:00Z","MessageID":"66667777888899990000111122223333","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Dinner on Friday","Payment":{"Status":"requested","RequestMessageID":"66667777888899990000111122223333","Amount1000":250500,"CurrencyCode":"INR","RequesterJID":"123456789@s.whatsapp.net","RequestFromJID":"987654321@s.whatsapp.net","Note":"Dinner on Friday"}}`),
+	},
+	{
+		note:                "Incoming payment request decline",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"77778888999900001111222233334444","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T15:05:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"declinePaymentRequestMessage":{"key":{"fromMe":true,"ID":"66667777888899990000111122223333","remoteJID":"123456789@s.whatsapp.net"}}},"NewsletterMeta":null,"RawMessage":{"declinePaymentRequestMessage":{"key":{"fromMe":true,"ID":"66667777888899990000111122223333","remoteJID":"123456789@s.whatsapp.net"}}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"MessageID":"66667777888899990000111122223333","ChatJID":"123456789@s.whatsapp.net","Payment":{"Status":"declined","RequestMessageID":"66667777888899990000111122223333"}}`),
+	},
+	{
+		note:                "Incoming event",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0A1B2C3D4E5F60718","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"event","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"eventMessage":{"isCanceled":false,"name":"Braai at the park","description":"Bring your own meat","location":{"degreesLatitude":-33.9249,"degreesLongitude":18.4241,"name":"Green Point Park"},"extraGuestsAllowed":true}},"NewsletterMeta":null,"RawMessage":{"eventMessage":{"isCanceled":false,"name":"Braai at the park","description":"Bring your own meat","location":{"degreesLatitude":-33.9249,"degreesLongitude":18.4241,"name":"Green Point Park"},"extraGuestsAllowed":true}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-03-01T10:00:00+02:00","MessageID":"3EB0A1B2C3D4E5F60718","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"event","ContentBody":"Braai at the park","Event":{"Name":"Braai at the park","Description":"Bring your own meat","LocationLat":-33.9249,"LocationLon":18.4241,"LocationName":"Green Point Park","IsCanceled":false,"ExtraGuestsAllowed":true}}`),
+	},
+	{
+		note:                "Incoming pin in chat",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000002","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"pinInChatMessage":{"key":{"remoteJID":"123456789@s.whatsapp.net","fromMe":true,"ID":"3EB0C0FFEE0000000001"},"type":1,"senderTimestampMS":1740816000000},"messageContextInfo":{"messageAddOnDurationInSecs":604800}},"NewsletterMeta":null,"RawMessage":{"pinInChatMessage":{"key":{"remoteJID":"123456789@s.whatsapp.net","fromMe":true,"ID":"3EB0C0FFEE0000000001"},"type":1,"senderTimestampMS":1740816000000},"messageContextInfo":{"messageAddOnDurationInSecs":604800}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"MessageID":"3EB0C0FFEE0000000001","ChatJID":"123456789@s.whatsapp.net","Pinned":true,"PinDurationSeconds":604800}`),
+	},
+	{
+		note:                "Incoming scheduled call",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000003","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"scheduledCallCreationMessage":{"callType":2,"title":"Weekly standup"}},"NewsletterMeta":null,"RawMessage":{"scheduledCallCreationMessage":{"callType":2,"title":"Weekly standup"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-03-01T10:00:00+02:00","MessageID":"3EB0C0FFEE0000000003","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Weekly standup","ScheduledCallTitle":"Weekly standup","ScheduledCallType":"video","ScheduledCallCancelled":false}`),
+	},
+	{
+		note:                "Incoming scheduled call cancel",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000004","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"scheduledCallEditMessage":{"key":{"remoteJID":"123456789@s.whatsapp.net","fromMe":false,"ID":"3EB0C0FFEE0000000003"},"editType":1}},"NewsletterMeta":null,"RawMessage":{"scheduledCallEditMessage":{"key":{"remoteJID":"123456789@s.whatsapp.net","fromMe":false,"ID":"3EB0C0FFEE0000000003"},"editType":1}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"MessageID":"3EB0C0FFEE0000000003","ChatJID":"123456789@s.whatsapp.net","Edited":true,"ScheduledCallCancelled":true}`),
+	},
+	{
+		note:                "Incoming status mention",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000006","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"statusMentionMessage":{"message":{"conversation":"Thanks for the great service"}}},"NewsletterMeta":null,"RawMessage":{"statusMentionMessage":{"message":{"conversation":"Thanks for the great service"}}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-03-01T10:00:00+02:00","MessageID":"3EB0C0FFEE0000000006","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Thanks for the great service","StatusMention":{"Timestamp":"2025-03-01T10:00:00+02:00","Expiry":"2025-03-02T10:00:00+02:00","PosterJID":"123456789@s.whatsapp.net","IsFromMe":false,"Caption":"Thanks for the great service"}}`),
+	},
+	{
+		note:                "Incoming group invite",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000008","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"groupInviteMessage":{"groupJID":"120363000000000002@g.us","inviteCode":"AbCdEf123","groupName":"Fleet drivers","caption":"Join the drivers group"}},"NewsletterMeta":null,"RawMessage":{"groupInviteMessage":{"groupJID":"120363000000000002@g.us","inviteCode":"AbCdEf123","groupName":"Fleet drivers","caption":"Join the drivers group"}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-03-01T10:00:00+02:00","MessageID":"3EB0C0FFEE0000000008","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Join the drivers group","GroupInvite":{"GroupJID":"120363000000000002@g.us","GroupName":"Fleet drivers","InviteCode":"AbCdEf123","InviterJID":"123456789@s.whatsapp.net"}}`),
+	},
+	{
+		note:                "Incoming round video note",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000103","IsFromMe":false,"IsGroup":false,"MediaType":"ptv","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"media","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"ptvMessage":{"mimetype":"video/mp4","seconds":12,"width":480,"height":480}},"NewsletterMeta":null,"RawMessage":{"ptvMessage":{"mimetype":"video/mp4","seconds":12,"width":480,"height":480}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-03-01T10:00:00+02:00","MessageID":"3EB0C0FFEE0000000103","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"media","ContentBody":"","VideoNote":true}`),
+	},
+	{
+		note:                "Incoming event response that can't be decrypted",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000104","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"encEventResponseMessage":{"eventCreationMessageKey":{"remoteJID":"123456789@s.whatsapp.net","fromMe":true,"ID":"3EB0C0FFEE0000000004"}}},"NewsletterMeta":null,"RawMessage":{"encEventResponseMessage":{"eventCreationMessageKey":{"remoteJID":"123456789@s.whatsapp.net","fromMe":true,"ID":"3EB0C0FFEE0000000004"}}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"MessageID":"3EB0C0FFEE0000000004","ChatJID":"123456789@s.whatsapp.net"}`),
+	},
+	{
+		note:                "Incoming list response without context info",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000102","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T13:05:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"listResponseMessage":{"title":"Track my order","listType":1,"singleSelectReply":{"selectedRowID":"track-order"}}},"NewsletterMeta":null,"RawMessage":{"listResponseMessage":{"title":"Track my order","listType":1,"singleSelectReply":{"selectedRowID":"track-order"}}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T13:05:00Z","MessageID":"3EB0C0FFEE0000000102","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Track my order","Selection":{"Type":"list","SelectedID":"track-order","DisplayText":"Track my order"}}`),
+	},
+	{
+		note:                "Incoming reply to an image",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000101","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-05-02T13:00:00Z","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"extendedTextMessage":{"text":"Is this the right part?","contextInfo":{"stanzaID":"ABCDEF0123456789ABCDEF0123456789","participant":"123456789@s.whatsapp.net","quotedMessage":{"imageMessage":{"mimetype":"image/jpeg","width":640,"height":480}}}}},"NewsletterMeta":null,"RawMessage":{"extendedTextMessage":{"text":"Is this the right part?","contextInfo":{"stanzaID":"ABCDEF0123456789ABCDEF0123456789","participant":"123456789@s.whatsapp.net","quotedMessage":{"imageMessage":{"mimetype":"image/jpeg","width":640,"height":480}}}}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"Timestamp":"2025-05-02T13:00:00Z","MessageID":"3EB0C0FFEE0000000101","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","IsFromMe":false,"Type":"text","ContentBody":"Is this the right part?","InfoQuotedMessageID":"ABCDEF0123456789ABCDEF0123456789","InfoQuotedMessage":{"MessageID":"ABCDEF0123456789ABCDEF0123456789","ChatJID":"123456789@s.whatsapp.net","SenderJID":"123456789@s.whatsapp.net","Type":"media","ContentBody":""},"InfoParticipant":"123456789@s.whatsapp.net"}`),
+	},
+	{
+		note:                "Incoming event cover image that can't be downloaded",
+		eventMessageJSON:    []byte(`{"Info":{"AddressingMode":"","BroadcastListOwner":"","Category":"","Chat":"123456789@s.whatsapp.net","DeviceSentMeta":null,"Edit":"","ID":"3EB0C0FFEE0000000105","IsFromMe":false,"IsGroup":false,"MediaType":"","MsgBotInfo":{"EditSenderTimestampMS":"0001-01-01T00:00:00Z","EditTargetID":"","EditType":""},"MsgMetaInfo":{"DeprecatedLIDSession":null,"TargetID":"","TargetSender":"","ThreadMessageID":"","ThreadMessageSenderJID":""},"Multicast":false,"PushName":"Jow Blow","RecipientAlt":"","Sender":"123456789@s.whatsapp.net","SenderAlt":"123456789012345@lid","ServerID":0,"Timestamp":"2025-03-01T10:00:00+02:00","Type":"text","VerifiedName":null},"IsDocumentWithCaption":false,"IsEdit":false,"IsEphemeral":false,"IsLottieSticker":false,"IsViewOnce":false,"IsViewOnceV2":false,"IsViewOnceV2Extension":false,"Message":{"eventCoverImage":{"message":{"imageMessage":{"mimetype":"image/jpeg"}}},"messageContextInfo":{"messageAssociation":{"associationType":1,"parentMessageKey":{"remoteJID":"123456789@s.whatsapp.net","fromMe":false,"ID":"3EB0C0FFEE0000000004"}}}},"NewsletterMeta":null,"RawMessage":{"eventCoverImage":{"message":{"imageMessage":{"mimetype":"image/jpeg"}}},"messageContextInfo":{"messageAssociation":{"associationType":1,"parentMessageKey":{"remoteJID":"123456789@s.whatsapp.net","fromMe":false,"ID":"3EB0C0FFEE0000000004"}}}},"RetryCount":0,"SourceWebMsg":null,"UnavailableRequestID":""}`),
+		expectedMessageJSON: []byte(`{"MessageID":"3EB0C0FFEE0000000004","ChatJID":"123456789@s.whatsapp.net"}`),
 	},
 }
 
@@ -41,3 +135,51 @@ func TestParseEventMessage(t *testing.T) {
 		}
 	}
 }
+
+func TestParseQuotedMessage(t *testing.T) {
+	conn := &Connection{MediaPath: t.TempDir()}
+	reply := events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Chat: types.NewJID("123456789", types.DefaultUserServer)}}}
+	thumbnail := []byte("not really a jpeg")
+
+	quoted := conn.parseQuotedMessage(reply, &waE2E.ContextInfo{
+		StanzaID:    proto.String("ABCDEF0123456789ABCDEF0123456789"),
+		Participant: proto.String("987654321@s.whatsapp.net"),
+		QuotedMessage: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			URL:           proto.String("https://mmg.whatsapp.net/v/t62/image"),
+			Caption:       proto.String("Part 42"),
+			JPEGThumbnail: thumbnail,
+		}},
+	})
+	if quoted.MessageID != "ABCDEF0123456789ABCDEF0123456789" || quoted.ChatJID != "123456789@s.whatsapp.net" {
+		t.Errorf("Unexpected quoted message key: %+v", quoted)
+	}
+	if stringOrEmpty(quoted.SenderJID) != "987654321@s.whatsapp.net" || stringOrEmpty(quoted.Type) != "media" || stringOrEmpty(quoted.ContentBody) != "Part 42" {
+		t.Errorf("Unexpected quoted message: %+v", quoted)
+	}
+	if len(quoted.Attachments) != 1 || quoted.Attachments[0] != conn.hashFile(thumbnail)+".jpeg" {
+		t.Errorf("Expected only the thumbnail as attachment, got %v", quoted.Attachments)
+	}
+
+	quoted = conn.parseQuotedMessage(reply, &waE2E.ContextInfo{
+		StanzaID: proto.String("ABCDEF0123456789ABCDEF0123456790"),
+		QuotedMessage: &waE2E.Message{PinInChatMessage: &waE2E.PinInChatMessage{
+			Key:  &waCommon.MessageKey{ID: proto.String("ABCDEF0123456789ABCDEF0123456789")},
+			Type: waE2E.PinInChatMessage_PIN_FOR_ALL.Enum(),
+		}},
+	})
+	if quoted.MessageID != "ABCDEF0123456789ABCDEF0123456790" {
+		t.Errorf("Expected the pin message itself rather than the pinned message, got %s", quoted.MessageID)
+	}
+
+	quoted = conn.parseQuotedMessage(reply, &waE2E.ContextInfo{
+		StanzaID: proto.String("ABCDEF0123456789ABCDEF0123456791"),
+		QuotedMessage: &waE2E.Message{LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(-33.9249),
+			DegreesLongitude: proto.Float64(18.4241),
+			Name:             proto.String("Depot"),
+		}},
+	})
+	if quoted.LocationLat == nil || *quoted.LocationLat != -33.9249 || stringOrEmpty(quoted.LocationName) != "Depot" {
+		t.Errorf("Expected the quoted location, got %v %v", quoted.LocationLat, quoted.LocationName)
+	}
+}
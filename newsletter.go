@@ -0,0 +1,374 @@
+package whatsmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// Newsletter is a WhatsApp channel. Updates only have the fields that changed set.
+type Newsletter struct {
+	JID string `json:",omitempty"`
+
+	Name            *string    `json:",omitempty"` // not always set
+	Description     *string    `json:",omitempty"` // not always set
+	Picture         *string    `json:",omitempty"` // the saved picture file name in the media path, not always set
+	InviteCode      *string    `json:",omitempty"` // the code in the https://whatsapp.com/channel/ link, not always set
+	SubscriberCount *int       `json:",omitempty"` // not always set
+	Verified        *bool      `json:",omitempty"` // not always set
+	State           *string    `json:",omitempty"` // one of the types.NewsletterState values, not always set
+	CreationTime    *time.Time `json:",omitempty"` // not always set
+
+	Following *bool           `json:",omitempty"` // not always set
+	Role      *NewsletterRole `json:",omitempty"` // use NewsletterRole* constants, not always set
+	Muted     *bool           `json:",omitempty"` // not always set
+}
+
+// NewsletterAdminInvite is an invite to become an admin of a channel.
+type NewsletterAdminInvite struct {
+	NewsletterJID  string     `json:",omitempty"`
+	NewsletterName *string    `json:",omitempty"` // not always set
+	Expiry         *time.Time `json:",omitempty"` // not always set
+}
+
+type NewsletterRole string
+
+const (
+	NewsletterRoleSubscriber NewsletterRole = "subscriber"
+	NewsletterRoleGuest      NewsletterRole = "guest"
+	NewsletterRoleAdmin      NewsletterRole = "admin"
+	NewsletterRoleOwner      NewsletterRole = "owner"
+)
+
+func (conn *Connection) handleNewsletter(newsletter Newsletter) {
+	if conn.Callbacks.Newsletter == nil {
+		return
+	}
+	conn.Callbacks.Newsletter(newsletter)
+}
+
+func (conn *Connection) parseNewsletterMetadata(metadata types.NewsletterMetadata) Newsletter {
+	thread := metadata.ThreadMeta
+	newsletter := Newsletter{
+		JID:             metadata.ID.String(),
+		Name:            nonEmpty(thread.Name.Text),
+		Description:     nonEmpty(thread.Description.Text),
+		InviteCode:      nonEmpty(thread.InviteCode),
+		SubscriberCount: &thread.SubscriberCount,
+		Verified:        proto.Bool(thread.VerificationState == types.NewsletterVerificationStateVerified),
+		State:           nonEmpty(string(metadata.State.Type)),
+	}
+	if created := thread.CreationTime.Time; !created.IsZero() {
+		newsletter.CreationTime = &created
+	}
+	if picture := thread.Picture; picture != nil {
+		imageName, err := conn.saveNewsletterPicture(picture)
+		if err != nil {
+			conn.Log.Warn().Str("jid", newsletter.JID).Err(err).Msg("failed to pull newsletter picture")
+		} else if imageName != "" {
+			newsletter.Picture = &imageName
+		}
+	}
+	if viewer := metadata.ViewerMeta; viewer != nil {
+		role := NewsletterRole(viewer.Role)
+		newsletter.Role = &role
+		newsletter.Muted = proto.Bool(viewer.Mute == types.NewsletterMuteOn)
+		newsletter.Following = proto.Bool(viewer.Role != types.NewsletterRoleGuest)
+	}
+	return newsletter
+}
+
+// saveNewsletterPicture saves the channel picture, downloading it from the media hosts when only the path is known.
+func (conn *Connection) saveNewsletterPicture(picture *types.ProfilePictureInfo) (imageName string, err error) {
+	if picture.URL != "" {
+		return conn.saveProfilePhoto(picture.URL)
+	}
+	if picture.DirectPath == "" {
+		return "", nil
+	}
+	// channel pictures aren't encrypted, so there is no media key or hash to check
+	raw, err := conn.client.DownloadMediaWithPath(context.Background(), picture.DirectPath, nil, nil, nil, -1, whatsmeow.MediaImage, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to download newsletter picture: %w", err)
+	}
+	return conn.saveAttachment(raw, "", ".jpeg")
+}
+
+// parseNewsletterMessage turns a channel post fetched from the server, or the counts in a live update, into a
+// Message. Live updates don't include the post itself, so only the IDs and counts are set.
+func (conn *Connection) parseNewsletterMessage(jid types.JID, post *types.NewsletterMessage) Message {
+	var message Message
+	if post.Message != nil {
+		message = conn.parseEventMessage(events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{
+					Chat:   jid,
+					Sender: jid,
+				},
+				ID:        post.MessageID,
+				ServerID:  post.MessageServerID,
+				Type:      post.Type,
+				Timestamp: post.Timestamp,
+			},
+			Message:    post.Message,
+			RawMessage: post.Message,
+		})
+	} else {
+		message = Message{
+			MessageID: post.MessageID,
+			ChatJID:   jid.String(),
+			Raw:       post,
+		}
+	}
+	serverID := int(post.MessageServerID)
+	message.ServerID = &serverID
+	message.ViewCount = &post.ViewsCount
+	message.ReactionCounts = post.ReactionCounts
+	return message
+}
+
+// GetNewsletter fetches a channel by its JID.
+func (conn *Connection) GetNewsletter(newsletterJID string) (Newsletter, error) {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return Newsletter{}, fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	metadata, err := conn.client.GetNewsletterInfo(jid)
+	if err != nil {
+		return Newsletter{}, fmt.Errorf("failed to get newsletter info: %w", err)
+	}
+	return conn.parseNewsletterMetadata(*metadata), nil
+}
+
+// GetNewsletterFromInvite fetches a channel by the invite code or link, without following it.
+func (conn *Connection) GetNewsletterFromInvite(invite string) (Newsletter, error) {
+	metadata, err := conn.client.GetNewsletterInfoWithInvite(invite)
+	if err != nil {
+		return Newsletter{}, fmt.Errorf("failed to get newsletter info from invite: %w", err)
+	}
+	return conn.parseNewsletterMetadata(*metadata), nil
+}
+
+// GetFollowedNewsletters fetches all the channels we follow or administer.
+func (conn *Connection) GetFollowedNewsletters() ([]Newsletter, error) {
+	list, err := conn.client.GetSubscribedNewsletters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribed newsletters: %w", err)
+	}
+	newsletters := []Newsletter{}
+	for _, metadata := range list {
+		if metadata == nil {
+			continue
+		}
+		newsletters = append(newsletters, conn.parseNewsletterMetadata(*metadata))
+	}
+	return newsletters, nil
+}
+
+// GetNewsletterMessages fetches up to count posts from a channel, before the given server ID or the latest posts
+// if before is 0.
+func (conn *Connection) GetNewsletterMessages(newsletterJID string, count int, before int) ([]Message, error) {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	posts, err := conn.client.GetNewsletterMessages(jid, &whatsmeow.GetNewsletterMessagesParams{
+		Count:  count,
+		Before: types.MessageServerID(before),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get newsletter messages: %w", err)
+	}
+	messages := []Message{}
+	for _, post := range posts {
+		if post == nil {
+			continue
+		}
+		messages = append(messages, conn.parseNewsletterMessage(jid, post))
+	}
+	return messages, nil
+}
+
+func (conn *Connection) FollowNewsletter(newsletterJID string) error {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	if err := conn.client.FollowNewsletter(jid); err != nil {
+		return fmt.Errorf("failed to follow newsletter: %w", err)
+	}
+	return nil
+}
+
+func (conn *Connection) UnfollowNewsletter(newsletterJID string) error {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	if err := conn.client.UnfollowNewsletter(jid); err != nil {
+		return fmt.Errorf("failed to unfollow newsletter: %w", err)
+	}
+	return nil
+}
+
+func (conn *Connection) MuteNewsletter(newsletterJID string, mute bool) error {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	if err := conn.client.NewsletterToggleMute(jid, mute); err != nil {
+		return fmt.Errorf("failed to toggle newsletter mute: %w", err)
+	}
+	return nil
+}
+
+// SubscribeNewsletterLiveUpdates asks the server to send view and reaction count updates for a channel as Message
+// updates, the subscription lasts for the returned duration and has to be renewed after that.
+func (conn *Connection) SubscribeNewsletterLiveUpdates(newsletterJID string) (time.Duration, error) {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	duration, err := conn.client.NewsletterSubscribeLiveUpdates(conn.ctx, jid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to subscribe to newsletter live updates: %w", err)
+	}
+	return duration, nil
+}
+
+// mutationUpdateNewsletter is the web client query ID for updating a channel, copied from the unexported
+// mutationUpdateNewsletter constant in whatsmeow's newsletter.go, which has no method using it yet. whatsmeow's
+// sendMexIQ converts it to the desktop query ID when needed.
+const mutationUpdateNewsletter = "7150902998257522"
+
+// CreateNewsletter creates a channel with the Name, Description and Picture of newsletter, where Picture is a JPEG
+// file name in the media path. The WhatsApp channel terms have to be accepted on the account first.
+func (conn *Connection) CreateNewsletter(newsletter Newsletter) (Newsletter, error) {
+	if newsletter.Name == nil || *newsletter.Name == "" {
+		return newsletter, errors.New("missing newsletter.Name")
+	}
+	params := whatsmeow.CreateNewsletterParams{
+		Name:        *newsletter.Name,
+		Description: stringOrEmpty(newsletter.Description),
+	}
+	if newsletter.Picture != nil {
+		picture, err := conn.readPicture(*newsletter.Picture)
+		if err != nil {
+			return newsletter, err
+		}
+		params.Picture = picture
+	}
+	metadata, err := conn.client.CreateNewsletter(params)
+	if err != nil {
+		return newsletter, fmt.Errorf("failed to create newsletter: %w", err)
+	}
+	return conn.parseNewsletterMetadata(*metadata), nil
+}
+
+// UpdateNewsletter changes the Name, Description and Picture of a channel we administer, only the fields that are
+// set are changed.
+func (conn *Connection) UpdateNewsletter(newsletter Newsletter) (Newsletter, error) {
+	jid, err := types.ParseJID(newsletter.JID)
+	if err != nil {
+		return newsletter, fmt.Errorf("failed to parse newsletter.JID ('%s'): %w", newsletter.JID, err)
+	}
+	var picture []byte
+	if newsletter.Picture != nil {
+		picture, err = conn.readPicture(*newsletter.Picture)
+		if err != nil {
+			return newsletter, err
+		}
+	}
+	variables, err := buildNewsletterUpdate(jid, newsletter, picture)
+	if err != nil {
+		return newsletter, err
+	}
+	// whatsmeow only exposes the GraphQL queries it uses itself through its internals
+	resp, err := conn.client.DangerousInternals().SendMexIQ(context.Background(), mutationUpdateNewsletter, variables)
+	if err != nil {
+		return newsletter, fmt.Errorf("failed to update newsletter: %w", err)
+	}
+	metadata, err := parseNewsletterUpdate(resp)
+	if err != nil {
+		return newsletter, err
+	}
+	if metadata == nil {
+		return newsletter, nil
+	}
+	return conn.parseNewsletterMetadata(*metadata), nil
+}
+
+// buildNewsletterUpdate builds the variables of mutationUpdateNewsletter, with only the fields that are set.
+func buildNewsletterUpdate(jid types.JID, newsletter Newsletter, picture []byte) (map[string]any, error) {
+	updates := map[string]any{}
+	if newsletter.Name != nil {
+		updates["name"] = *newsletter.Name
+	}
+	if newsletter.Description != nil {
+		updates["description"] = *newsletter.Description
+	}
+	if picture != nil {
+		updates["picture"] = picture
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("nothing to update")
+	}
+	return map[string]any{
+		"newsletter_id": jid.String(),
+		"updates":       updates,
+	}, nil
+}
+
+// parseNewsletterUpdate parses the response to mutationUpdateNewsletter, the metadata is nil when it isn't included.
+func parseNewsletterUpdate(resp json.RawMessage) (*types.NewsletterMetadata, error) {
+	var respData struct {
+		Newsletter *types.NewsletterMetadata `json:"xwa2_newsletter_update"`
+	}
+	if err := json.Unmarshal(resp, &respData); err != nil {
+		return nil, fmt.Errorf("failed to parse newsletter update response: %w", err)
+	}
+	return respData.Newsletter, nil
+}
+
+// GetNewsletterPostStats fetches the view and reaction counts of up to count posts in a channel we administer that
+// changed since the given time, the posts themselves are not included.
+func (conn *Connection) GetNewsletterPostStats(newsletterJID string, since time.Time, count int) ([]Message, error) {
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse newsletterJID ('%s'): %w", newsletterJID, err)
+	}
+	posts, err := conn.client.GetNewsletterMessageUpdates(jid, &whatsmeow.GetNewsletterUpdatesParams{
+		Count: count,
+		Since: since,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get newsletter message updates: %w", err)
+	}
+	messages := []Message{}
+	for _, post := range posts {
+		if post == nil {
+			continue
+		}
+		messages = append(messages, parseNewsletterPostStats(jid, post))
+	}
+	return messages, nil
+}
+
+// parseNewsletterPostStats turns the counts of a channel post into a Message, without parsing the post itself.
+func parseNewsletterPostStats(jid types.JID, post *types.NewsletterMessage) Message {
+	serverID := int(post.MessageServerID)
+	return Message{
+		MessageID:      post.MessageID,
+		ChatJID:        jid.String(),
+		ServerID:       &serverID,
+		ViewCount:      &post.ViewsCount,
+		ReactionCounts: post.ReactionCounts,
+	}
+}
@@ -0,0 +1,155 @@
+package whatsmgr
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseNewsletterMessage(t *testing.T) {
+	jid := types.NewJID("120363000000000001", types.NewsletterServer)
+	tests := []struct {
+		note        string
+		post        types.NewsletterMessage
+		contentBody string
+	}{
+		{
+			note: "fetched post",
+			post: types.NewsletterMessage{
+				MessageServerID: 105,
+				MessageID:       "3EB0C0FFEE0000000007",
+				Type:            "text",
+				Timestamp:       time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+				ViewsCount:      1200,
+				ReactionCounts:  map[string]int{"👍": 40, "❤️": 12},
+				Message:         &waE2E.Message{Conversation: proto.String("Fuel prices drop next week")},
+			},
+			contentBody: "Fuel prices drop next week",
+		},
+		{
+			note: "live update",
+			post: types.NewsletterMessage{
+				MessageServerID: 105,
+				MessageID:       "3EB0C0FFEE0000000007",
+				ViewsCount:      1200,
+				ReactionCounts:  map[string]int{"👍": 40, "❤️": 12},
+			},
+		},
+	}
+	for _, test := range tests {
+		message := (&Connection{}).parseNewsletterMessage(jid, &test.post)
+		if message.MessageID != "3EB0C0FFEE0000000007" || message.ChatJID != jid.String() {
+			t.Errorf("%s: Unexpected message ID or chat: %s %s", test.note, message.MessageID, message.ChatJID)
+		}
+		if message.ServerID == nil || *message.ServerID != 105 {
+			t.Errorf("%s: Expected server ID 105, got %v", test.note, message.ServerID)
+		}
+		if message.ViewCount == nil || *message.ViewCount != 1200 {
+			t.Errorf("%s: Expected 1200 views, got %v", test.note, message.ViewCount)
+		}
+		if message.ReactionCounts["👍"] != 40 {
+			t.Errorf("%s: Expected 40 👍 reactions, got %d", test.note, message.ReactionCounts["👍"])
+		}
+		if stringOrEmpty(message.ContentBody) != test.contentBody {
+			t.Errorf("%s: Expected content %q, got %q", test.note, test.contentBody, stringOrEmpty(message.ContentBody))
+		}
+	}
+}
+
+func TestParseNewsletterMetadata(t *testing.T) {
+	metadata := types.NewsletterMetadata{
+		ID: types.NewJID("120363000000000001", types.NewsletterServer),
+		ThreadMeta: types.NewsletterThreadMetadata{
+			InviteCode:        "0029VaAbCdEfGhIjKlMnOp",
+			Name:              types.NewsletterText{Text: "Industry News"},
+			SubscriberCount:   5400,
+			VerificationState: types.NewsletterVerificationStateVerified,
+		},
+		ViewerMeta: &types.NewsletterViewerMetadata{
+			Mute: types.NewsletterMuteOn,
+			Role: types.NewsletterRoleSubscriber,
+		},
+	}
+	newsletter := (&Connection{}).parseNewsletterMetadata(metadata)
+	if stringOrEmpty(newsletter.Name) != "Industry News" || stringOrEmpty(newsletter.InviteCode) != "0029VaAbCdEfGhIjKlMnOp" {
+		t.Errorf("Unexpected name or invite code: %+v", newsletter)
+	}
+	if newsletter.Description != nil || newsletter.CreationTime != nil || newsletter.Picture != nil {
+		t.Errorf("Expected unset fields to be nil: %+v", newsletter)
+	}
+	if newsletter.Verified == nil || !*newsletter.Verified || newsletter.Muted == nil || !*newsletter.Muted {
+		t.Errorf("Expected a verified and muted newsletter: %+v", newsletter)
+	}
+	if newsletter.Following == nil || !*newsletter.Following || newsletter.Role == nil || *newsletter.Role != NewsletterRoleSubscriber {
+		t.Errorf("Expected a followed newsletter with the subscriber role: %+v", newsletter)
+	}
+}
+
+func TestBuildNewsletterUpdate(t *testing.T) {
+	jid := types.NewJID("120363000000000001", types.NewsletterServer)
+
+	variables, err := buildNewsletterUpdate(jid, Newsletter{Name: proto.String("Specials"), Description: proto.String("")}, []byte{0xff, 0xd8})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	raw, err := json.Marshal(variables)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `{"newsletter_id":"120363000000000001@newsletter","updates":{"description":"","name":"Specials","picture":"/9g="}}`
+	if string(raw) != expected {
+		t.Errorf("Expected %s, got %s", expected, raw)
+	}
+
+	if _, err := buildNewsletterUpdate(jid, Newsletter{JID: jid.String()}, nil); err == nil {
+		t.Errorf("Expected an error when nothing is set, got nil")
+	}
+}
+
+func TestParseNewsletterUpdate(t *testing.T) {
+	metadata, err := parseNewsletterUpdate(json.RawMessage(`{"xwa2_newsletter_update":{"id":"120363000000000001@newsletter","thread_metadata":{"name":{"text":"Specials"}}}}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if metadata == nil || metadata.ID.String() != "120363000000000001@newsletter" || metadata.ThreadMeta.Name.Text != "Specials" {
+		t.Errorf("Unexpected metadata: %+v", metadata)
+	}
+
+	metadata, err = parseNewsletterUpdate(json.RawMessage(`{}`))
+	if err != nil || metadata != nil {
+		t.Errorf("Expected no metadata and no error, got %+v %v", metadata, err)
+	}
+	if _, err := parseNewsletterUpdate(json.RawMessage(`[`)); err == nil {
+		t.Errorf("Expected an error for an invalid response, got nil")
+	}
+}
+
+func TestParseNewsletterPostStats(t *testing.T) {
+	jid := types.NewJID("120363000000000001", types.NewsletterServer)
+	message := parseNewsletterPostStats(jid, &types.NewsletterMessage{
+		MessageServerID: 105,
+		MessageID:       "3EB0C0FFEE0000000010",
+		ViewsCount:      42,
+		ReactionCounts:  map[string]int{"👍": 3},
+		Message:         &waE2E.Message{Conversation: proto.String("Open all weekend")},
+	})
+	if message.MessageID != "3EB0C0FFEE0000000010" || message.ChatJID != jid.String() {
+		t.Errorf("Unexpected IDs: %s %s", message.MessageID, message.ChatJID)
+	}
+	if message.ServerID == nil || *message.ServerID != 105 {
+		t.Errorf("Expected ServerID 105, got %v", message.ServerID)
+	}
+	if message.ViewCount == nil || *message.ViewCount != 42 {
+		t.Errorf("Expected ViewCount 42, got %v", message.ViewCount)
+	}
+	if message.ReactionCounts["👍"] != 3 {
+		t.Errorf("Expected 3 👍 reactions, got %v", message.ReactionCounts)
+	}
+	if message.ContentBody != nil || message.Raw != nil {
+		t.Errorf("Expected the post itself to be left out, got %v %v", message.ContentBody, message.Raw)
+	}
+}
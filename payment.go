@@ -0,0 +1,87 @@
+package whatsmgr
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// Payment is a WhatsApp payment, amounts are multiplied by 1000 to avoid rounding.
+type Payment struct {
+	Status           PaymentStatus `json:",omitempty"` // use PaymentStatus* constants
+	RequestMessageID *string       `json:",omitempty"` // the ID of the payment request message this belongs to, not always set
+	Amount1000       *int64        `json:",omitempty"` // not always set
+	CurrencyCode     *string       `json:",omitempty"` // ISO 4217, not always set
+	RequesterJID     *string       `json:",omitempty"` // who is asking to be paid, not always set
+	RequestFromJID   *string       `json:",omitempty"` // who is being asked to pay, not always set
+	Expiry           *time.Time    `json:",omitempty"` // when the request or invite expires, not always set
+	Note             *string       `json:",omitempty"` // not always set
+	ServiceType      *string       `json:",omitempty"` // the payment service of an invite, e.g. UPI, not always set
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusRequested PaymentStatus = "requested"
+	PaymentStatusSent      PaymentStatus = "sent"
+	PaymentStatusDeclined  PaymentStatus = "declined"
+	PaymentStatusCancelled PaymentStatus = "cancelled"
+	PaymentStatusInvited   PaymentStatus = "invited"
+)
+
+func parseRequestPaymentMessage(x *waE2E.RequestPaymentMessage, messageID string, senderJID *string) Payment {
+	payment := Payment{
+		Status:           PaymentStatusRequested,
+		RequestMessageID: &messageID,
+		CurrencyCode:     x.CurrencyCodeIso4217,
+		RequesterJID:     senderJID,
+		RequestFromJID:   x.RequestFrom,
+		Note:             messageBody(x.NoteMessage),
+	}
+	if x.Amount1000 != nil {
+		amount := int64(*x.Amount1000)
+		payment.Amount1000 = &amount
+	} else if x.Amount != nil {
+		payment.Amount1000, payment.CurrencyCode = moneyAmount1000(x.Amount), x.Amount.CurrencyCode
+	}
+	if x.GetExpiryTimestamp() > 0 {
+		expiry := time.Unix(x.GetExpiryTimestamp(), 0)
+		payment.Expiry = &expiry
+	}
+	return payment
+}
+
+func parseSendPaymentMessage(x *waE2E.SendPaymentMessage) Payment {
+	return Payment{
+		Status:           PaymentStatusSent,
+		RequestMessageID: nonEmpty(x.GetRequestMessageKey().GetID()),
+		Note:             messageBody(x.NoteMessage),
+	}
+}
+
+func parsePaymentInviteMessage(x *waE2E.PaymentInviteMessage) Payment {
+	payment := Payment{
+		Status: PaymentStatusInvited,
+	}
+	if x.ServiceType != nil {
+		serviceType := x.ServiceType.String()
+		payment.ServiceType = &serviceType
+	}
+	if x.GetExpiryTimestamp() > 0 {
+		expiry := time.Unix(x.GetExpiryTimestamp(), 0)
+		payment.Expiry = &expiry
+	}
+	return payment
+}
+
+// moneyAmount1000 converts a Money value, which is Value / 10^Offset, to an amount multiplied by 1000.
+func moneyAmount1000(money *waE2E.Money) *int64 {
+	if money.Value == nil {
+		return nil
+	}
+	amount := money.GetValue() * 1000
+	for range money.GetOffset() {
+		amount /= 10
+	}
+	return &amount
+}
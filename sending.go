@@ -14,124 +14,57 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// SendMessage sends a message to a chat, group or channel that we administer.
 func (conn *Connection) SendMessage(message Message, sendOnCallback bool) (Message, error) {
-	var out waE2E.Message
+	jid, err := types.ParseJID(message.ChatJID)
+	if err != nil {
+		return message, fmt.Errorf("failed to parse ChatJID: %w", err)
+	}
+	var (
+		out   *waE2E.Message
+		extra whatsmeow.SendRequestExtra
+	)
 	if len(message.Attachments) > 0 {
-		attachment := message.Attachments[0]
-
-		parts := strings.Split(attachment, ".")
-		if len(parts) < 2 {
-			return message, fmt.Errorf("invalid attachment with no extension: %s", attachment)
+		out, extra.MediaHandle, err = conn.buildAttachmentMessage(jid, message)
+		if err != nil {
+			return message, err
 		}
-		ext := strings.ToLower(parts[len(parts)-1])
-
-		path := fmt.Sprintf("%s/%s", conn.MediaPath, attachment)
-		raw, err := os.ReadFile(path)
+	} else if message.Event != nil {
+		out, err = buildEventMessage(*message.Event)
 		if err != nil {
-			return message, fmt.Errorf("failed to read file to send: %w", err)
+			return message, err
 		}
-
-		switch ext {
-		case "jpg", "jpeg", "png":
-			resp, err := conn.client.Upload(context.Background(), raw, whatsmeow.MediaImage)
-			if err != nil {
-				return message, fmt.Errorf("failed to upload image to send: %w", err)
-			}
-			out.ImageMessage = &waE2E.ImageMessage{
-				Caption:       proto.String(*message.ContentBody),
-				Mimetype:      proto.String("image/" + ext),
-				URL:           &resp.URL,
-				DirectPath:    &resp.DirectPath,
-				MediaKey:      resp.MediaKey,
-				FileEncSHA256: resp.FileEncSHA256,
-				FileSHA256:    resp.FileSHA256,
-				FileLength:    &resp.FileLength,
-			}
-		case "mp4":
-			resp, err := conn.client.Upload(context.Background(), raw, whatsmeow.MediaVideo)
-			if err != nil {
-				return message, fmt.Errorf("failed to upload video to send: %w", err)
-			}
-			out.VideoMessage = &waE2E.VideoMessage{
-				Caption:       proto.String(*message.ContentBody),
-				Mimetype:      proto.String("video/" + ext),
-				URL:           &resp.URL,
-				DirectPath:    &resp.DirectPath,
-				MediaKey:      resp.MediaKey,
-				FileEncSHA256: resp.FileEncSHA256,
-				FileSHA256:    resp.FileSHA256,
-				FileLength:    &resp.FileLength,
-			}
-		case "aac", "amr", "mp3", "m4a", "ogg":
-			resp, err := conn.client.Upload(context.Background(), raw, whatsmeow.MediaAudio)
-			if err != nil {
-				return message, fmt.Errorf("failed to upload audio to send: %w", err)
-			}
-			mimeType := "audio/" + ext
-			switch ext {
-			case "mp3":
-				mimeType = "audio/mpeg"
-			case "m4a":
-				mimeType = "audio/mp4"
-			}
-			out.AudioMessage = &waE2E.AudioMessage{
-				Mimetype:      proto.String(mimeType),
-				URL:           &resp.URL,
-				DirectPath:    &resp.DirectPath,
-				MediaKey:      resp.MediaKey,
-				FileEncSHA256: resp.FileEncSHA256,
-				FileSHA256:    resp.FileSHA256,
-				FileLength:    &resp.FileLength,
-			}
-		case "txt", "xls", "xlsx", "doc", "docx", "ppt", "pptx", "pdf":
-			resp, err := conn.client.Upload(context.Background(), raw, whatsmeow.MediaDocument)
-			if err != nil {
-				return message, fmt.Errorf("failed to upload document to send: %w", err)
-			}
-			mimeType := ""
-			switch ext {
-			case "txt":
-				mimeType = "text/plain"
-			case "xls":
-				mimeType = "application/vnd.ms-excel"
-			case "xlsx":
-				mimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-			case "doc":
-				mimeType = "application/msword"
-			case "docx":
-				mimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
-			case "ppt":
-				mimeType = "application/vnd.ms-powerpoint"
-			case "pptx":
-				mimeType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
-			case "pdf":
-				mimeType = "application/pdf"
-			default:
-				return message, fmt.Errorf("unknown attachment type (could not determine mime type): %s", ext)
-			}
-			out.DocumentMessage = &waE2E.DocumentMessage{
-				Caption:       proto.String(*message.ContentBody),
-				Mimetype:      proto.String(mimeType),
-				URL:           &resp.URL,
-				DirectPath:    &resp.DirectPath,
-				MediaKey:      resp.MediaKey,
-				FileEncSHA256: resp.FileEncSHA256,
-				FileSHA256:    resp.FileSHA256,
-				FileLength:    &resp.FileLength,
-			}
-		default:
-			return message, fmt.Errorf("unknown attachment type: %s", ext)
+	} else if message.LocationLat != nil && message.LocationLon != nil {
+		out = buildLocationMessage(message, 0, 0)
+	} else if len(message.Contacts) > 0 {
+		contacts := []*waE2E.ContactMessage{}
+		for _, contact := range message.Contacts {
+			contacts = append(contacts, &waE2E.ContactMessage{
+				DisplayName: contactDisplayName(contact),
+				Vcard:       proto.String(contact.Vcard),
+			})
 		}
-	} else {
-		out = waE2E.Message{
-			Conversation: message.ContentBody,
+		displayName := message.ContactDisplayName
+		if displayName == nil {
+			displayName = proto.String(fmt.Sprintf("%d contacts", len(contacts)))
 		}
+		out = &waE2E.Message{
+			ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+				DisplayName: displayName,
+				Contacts:    contacts,
+			},
+		}
+	} else if message.ContactVcard != nil {
+		out = &waE2E.Message{
+			ContactMessage: &waE2E.ContactMessage{
+				DisplayName: contactDisplayName(SharedContact{DisplayName: message.ContactDisplayName, Vcard: *message.ContactVcard}),
+				Vcard:       message.ContactVcard,
+			},
+		}
+	} else {
+		out = conn.buildTextMessage(context.Background(), message.ContentBody)
 	}
-	jid, err := types.ParseJID(message.ChatJID)
-	if err != nil {
-		return message, fmt.Errorf("failed to parse ChatJID: %w", err)
-	}
-	resp, err := conn.client.SendMessage(context.Background(), jid, &out)
+	resp, err := conn.client.SendMessage(context.Background(), jid, out, extra)
 	if err != nil {
 		return message, fmt.Errorf("failed to send message: %w", err)
 	}
@@ -145,6 +78,187 @@ func (conn *Connection) SendMessage(message Message, sendOnCallback bool) (Messa
 	return message, nil
 }
 
+// buildAttachmentMessage uploads the first attachment and builds the media message for it, using
+// message.ContentBody as the caption. The media handle is only set for channel posts and has to be sent with them.
+func (conn *Connection) buildAttachmentMessage(chat types.JID, message Message) (out *waE2E.Message, mediaHandle string, err error) {
+	out = &waE2E.Message{}
+	attachment := message.Attachments[0]
+
+	parts := strings.Split(attachment, ".")
+	if len(parts) < 2 {
+		return nil, "", fmt.Errorf("invalid attachment with no extension: %s", attachment)
+	}
+	ext := strings.ToLower(parts[len(parts)-1])
+
+	path := fmt.Sprintf("%s/%s", conn.MediaPath, attachment)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file to send: %w", err)
+	}
+	upload := func(mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+		resp, err := conn.upload(chat, raw, mediaType)
+		mediaHandle = resp.Handle
+		return resp, err
+	}
+
+	switch ext {
+	case "jpg", "jpeg", "png":
+		resp, err := upload(whatsmeow.MediaImage)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upload image to send: %w", err)
+		}
+		out.ImageMessage = &waE2E.ImageMessage{
+			Caption:       proto.String(stringOrEmpty(message.ContentBody)),
+			Mimetype:      proto.String("image/" + ext),
+			URL:           &resp.URL,
+			DirectPath:    &resp.DirectPath,
+			MediaKey:      resp.MediaKey,
+			FileEncSHA256: resp.FileEncSHA256,
+			FileSHA256:    resp.FileSHA256,
+			FileLength:    &resp.FileLength,
+		}
+		width, height, thumbnail, err := imageThumbnail(raw)
+		if err != nil {
+			conn.Log.Warn().Err(err).Str("attachment", attachment).Msg("failed to generate image thumbnail")
+		} else {
+			out.ImageMessage.Width = &width
+			out.ImageMessage.Height = &height
+			out.ImageMessage.JPEGThumbnail = thumbnail
+		}
+	case "mp4":
+		resp, err := upload(whatsmeow.MediaVideo)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upload video to send: %w", err)
+		}
+		out.VideoMessage = &waE2E.VideoMessage{
+			Caption:       proto.String(stringOrEmpty(message.ContentBody)),
+			Mimetype:      proto.String("video/" + ext),
+			URL:           &resp.URL,
+			DirectPath:    &resp.DirectPath,
+			MediaKey:      resp.MediaKey,
+			FileEncSHA256: resp.FileEncSHA256,
+			FileSHA256:    resp.FileSHA256,
+			FileLength:    &resp.FileLength,
+		}
+		info, err := mp4Info(raw)
+		if err != nil {
+			conn.Log.Warn().Err(err).Str("attachment", attachment).Msg("failed to read video metadata")
+		} else {
+			out.VideoMessage.Seconds = &info.Seconds
+			out.VideoMessage.Width = &info.Width
+			out.VideoMessage.Height = &info.Height
+		}
+		if message.VideoNote != nil && *message.VideoNote {
+			asVideoNote(out)
+		}
+	case "webp":
+		resp, err := upload(whatsmeow.MediaImage)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upload sticker to send: %w", err)
+		}
+		out.StickerMessage = &waE2E.StickerMessage{
+			Mimetype:      proto.String("image/webp"),
+			URL:           &resp.URL,
+			DirectPath:    &resp.DirectPath,
+			MediaKey:      resp.MediaKey,
+			FileEncSHA256: resp.FileEncSHA256,
+			FileSHA256:    resp.FileSHA256,
+			FileLength:    &resp.FileLength,
+		}
+		info, err := webpInfo(raw)
+		if err != nil {
+			conn.Log.Warn().Err(err).Str("attachment", attachment).Msg("failed to read sticker metadata")
+		} else {
+			out.StickerMessage.Width = &info.Width
+			out.StickerMessage.Height = &info.Height
+			out.StickerMessage.IsAnimated = &info.Animated
+		}
+	case "aac", "amr", "mp3", "m4a", "ogg":
+		resp, err := upload(whatsmeow.MediaAudio)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upload audio to send: %w", err)
+		}
+		mimeType := "audio/" + ext
+		switch ext {
+		case "mp3":
+			mimeType = "audio/mpeg"
+		case "m4a":
+			mimeType = "audio/mp4"
+		case "ogg":
+			mimeType = "audio/ogg; codecs=opus"
+		}
+		out.AudioMessage = &waE2E.AudioMessage{
+			Mimetype:      proto.String(mimeType),
+			URL:           &resp.URL,
+			DirectPath:    &resp.DirectPath,
+			MediaKey:      resp.MediaKey,
+			FileEncSHA256: resp.FileEncSHA256,
+			FileSHA256:    resp.FileSHA256,
+			FileLength:    &resp.FileLength,
+			PTT:           proto.Bool(message.VoiceNote != nil && *message.VoiceNote),
+		}
+		if ext == "ogg" {
+			info, err := oggOpusInfo(raw)
+			if err != nil {
+				conn.Log.Warn().Err(err).Str("attachment", attachment).Msg("failed to read audio metadata")
+			} else {
+				out.AudioMessage.Seconds = &info.Seconds
+				out.AudioMessage.Waveform = info.Waveform
+			}
+		}
+	case "txt", "xls", "xlsx", "doc", "docx", "ppt", "pptx", "pdf":
+		resp, err := upload(whatsmeow.MediaDocument)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to upload document to send: %w", err)
+		}
+		mimeType := ""
+		switch ext {
+		case "txt":
+			mimeType = "text/plain"
+		case "xls":
+			mimeType = "application/vnd.ms-excel"
+		case "xlsx":
+			mimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		case "doc":
+			mimeType = "application/msword"
+		case "docx":
+			mimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case "ppt":
+			mimeType = "application/vnd.ms-powerpoint"
+		case "pptx":
+			mimeType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+		case "pdf":
+			mimeType = "application/pdf"
+		default:
+			return nil, "", fmt.Errorf("unknown attachment type (could not determine mime type): %s", ext)
+		}
+		fileName := attachment
+		if message.AttachmentFileName != nil && *message.AttachmentFileName != "" {
+			fileName = *message.AttachmentFileName
+		}
+		out.DocumentMessage = &waE2E.DocumentMessage{
+			Caption:       proto.String(stringOrEmpty(message.ContentBody)),
+			Mimetype:      proto.String(mimeType),
+			FileName:      proto.String(fileName),
+			Title:         proto.String(fileName),
+			URL:           &resp.URL,
+			DirectPath:    &resp.DirectPath,
+			MediaKey:      resp.MediaKey,
+			FileEncSHA256: resp.FileEncSHA256,
+			FileSHA256:    resp.FileSHA256,
+			FileLength:    &resp.FileLength,
+		}
+		if ext == "pdf" {
+			if pageCount := pdfPageCount(raw); pageCount > 0 {
+				out.DocumentMessage.PageCount = proto.Uint32(pageCount)
+			}
+		}
+	default:
+		return nil, "", fmt.Errorf("unknown attachment type: %s", ext)
+	}
+	return out, mediaHandle, nil
+}
+
 func (conn *Connection) SendEdit(message Message) error {
 	chat, err := types.ParseJID(message.ChatJID)
 	if err != nil {
@@ -163,14 +277,63 @@ func (conn *Connection) SendEdit(message Message) error {
 	return nil
 }
 
-func (conn *Connection) SendRead(messageIDs []string, when time.Time, chatJID string, senderJID string, receiptTypeExtra ...types.ReceiptType) error {
-	chat, err := types.ParseJID(chatJID)
+// SendPin pins or unpins a message in the chat for everyone, WhatsApp allows a duration of 24 hours, 7 days or 30 days.
+func (conn *Connection) SendPin(chatJID string, senderJID string, messageID string, pinned bool, duration time.Duration) error {
+	chat, sender, err := parseChatAndSender(chatJID, senderJID)
+	if err != nil {
+		return err
+	}
+	out := waE2E.Message{
+		PinInChatMessage: &waE2E.PinInChatMessage{
+			Key:               conn.client.BuildMessageKey(chat, sender, messageID),
+			Type:              waE2E.PinInChatMessage_UNPIN_FOR_ALL.Enum(),
+			SenderTimestampMS: proto.Int64(time.Now().UnixMilli()),
+		},
+	}
+	if pinned {
+		if duration <= 0 {
+			return errors.New("duration must be positive")
+		}
+		out.PinInChatMessage.Type = waE2E.PinInChatMessage_PIN_FOR_ALL.Enum()
+		out.MessageContextInfo = &waE2E.MessageContextInfo{
+			MessageAddOnDurationInSecs: proto.Uint32(uint32(duration.Seconds())),
+		}
+	}
+	_, err = conn.client.SendMessage(context.Background(), chat, &out)
+	if err != nil {
+		return fmt.Errorf("failed to send pin message: %w", err)
+	}
+	return nil
+}
+
+// SendKeep keeps a message from disappearing, or lets it disappear again, in a chat with disappearing messages.
+func (conn *Connection) SendKeep(chatJID string, senderJID string, messageID string, kept bool) error {
+	chat, sender, err := parseChatAndSender(chatJID, senderJID)
+	if err != nil {
+		return err
+	}
+	keepType := waE2E.KeepType_UNDO_KEEP_FOR_ALL
+	if kept {
+		keepType = waE2E.KeepType_KEEP_FOR_ALL
+	}
+	out := waE2E.Message{
+		KeepInChatMessage: &waE2E.KeepInChatMessage{
+			Key:         conn.client.BuildMessageKey(chat, sender, messageID),
+			KeepType:    keepType.Enum(),
+			TimestampMS: proto.Int64(time.Now().UnixMilli()),
+		},
+	}
+	_, err = conn.client.SendMessage(context.Background(), chat, &out)
 	if err != nil {
-		return fmt.Errorf("failed to parse chatJID ('%s'): %w", chatJID, err)
+		return fmt.Errorf("failed to send keep message: %w", err)
 	}
-	sender, err := types.ParseJID(senderJID)
+	return nil
+}
+
+func (conn *Connection) SendRead(messageIDs []string, when time.Time, chatJID string, senderJID string, receiptTypeExtra ...types.ReceiptType) error {
+	chat, sender, err := parseChatAndSender(chatJID, senderJID)
 	if err != nil {
-		return fmt.Errorf("failed to parse senderJID ('%s'): %w", senderJID, err)
+		return err
 	}
 	return conn.client.MarkRead(messageIDs, when, chat, sender, receiptTypeExtra...)
 }
@@ -178,3 +341,43 @@ func (conn *Connection) SendRead(messageIDs []string, when time.Time, chatJID st
 func (conn *Connection) SendPlayed(messageIDs []string, when time.Time, chatJID string, senderJID string) error {
 	return conn.SendRead(messageIDs, when, chatJID, senderJID, types.ReceiptTypePlayed)
 }
+
+func parseChatAndSender(chatJID string, senderJID string) (chat types.JID, sender types.JID, err error) {
+	chat, err = types.ParseJID(chatJID)
+	if err != nil {
+		return chat, sender, fmt.Errorf("failed to parse chatJID ('%s'): %w", chatJID, err)
+	}
+	sender, err = types.ParseJID(senderJID)
+	if err != nil {
+		return chat, sender, fmt.Errorf("failed to parse senderJID ('%s'): %w", senderJID, err)
+	}
+	return chat, sender, nil
+}
+
+// asVideoNote turns a video message into a round video note, which is a separate message type that can't have a
+// caption.
+func asVideoNote(out *waE2E.Message) {
+	out.PtvMessage = out.VideoMessage
+	out.PtvMessage.Caption = nil
+	out.VideoMessage = nil
+}
+
+// upload uploads media to send to chat, channel posts are not end-to-end encrypted so they are uploaded as is.
+func (conn *Connection) upload(chat types.JID, raw []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	if chat.Server == types.NewsletterServer {
+		return conn.client.UploadNewsletter(context.Background(), raw, mediaType)
+	}
+	return conn.client.Upload(context.Background(), raw, mediaType)
+}
+
+// contactDisplayName falls back to the name in the vCard when no display name was given.
+func contactDisplayName(contact SharedContact) *string {
+	if contact.DisplayName != nil {
+		return contact.DisplayName
+	}
+	card, err := ParseVCard(contact.Vcard)
+	if err != nil {
+		return nil
+	}
+	return proto.String(card.DisplayName())
+}
@@ -0,0 +1,31 @@
+package whatsmgr
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAsVideoNote(t *testing.T) {
+	out := &waE2E.Message{
+		VideoMessage: &waE2E.VideoMessage{
+			Caption:  proto.String("Delivered"),
+			Mimetype: proto.String("video/mp4"),
+			Seconds:  proto.Uint32(12),
+		},
+	}
+	asVideoNote(out)
+	if out.VideoMessage != nil {
+		t.Errorf("Expected no VideoMessage, got %v", out.VideoMessage)
+	}
+	if out.GetPtvMessage() == nil {
+		t.Fatalf("Expected PtvMessage to be set")
+	}
+	if out.GetPtvMessage().Caption != nil {
+		t.Errorf("Expected no caption, got %s", out.GetPtvMessage().GetCaption())
+	}
+	if out.GetPtvMessage().GetSeconds() != 12 {
+		t.Errorf("Expected Seconds 12, got %d", out.GetPtvMessage().GetSeconds())
+	}
+}
@@ -0,0 +1,222 @@
+package whatsmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// statusLifetime is how long a status stays visible after it is posted.
+const statusLifetime = 24 * time.Hour
+
+// Status is a status update (story), either posted to everyone in the poster's contacts or to a group.
+type Status struct {
+	Timestamp *time.Time `json:",omitempty"`
+	Expiry    *time.Time `json:",omitempty"` // when the status disappears, not always set
+
+	StatusID  string  `json:",omitempty"`
+	PosterJID string  `json:",omitempty"`
+	GroupJID  *string `json:",omitempty"` // set when the status was posted to a group, not always set
+
+	IsFromMe *bool `json:",omitempty"`
+
+	Caption     *string  `json:",omitempty"` // the text of a text status, or the caption of a media status, not always set
+	Attachments []string `json:",omitempty"` // not always set
+
+	TextFont           *string `json:",omitempty"` // one of the waE2E.ExtendedTextMessage_FontType names, not always set
+	TextColorARGB      *uint32 `json:",omitempty"` // not always set
+	TextBackgroundARGB *uint32 `json:",omitempty"` // not always set
+
+	IsAddYours *bool `json:",omitempty"` // the status is an "Add yours" prompt that others can respond to, not always set
+
+	Raw any `json:",omitempty"`
+}
+
+// StatusNotification tells the poster of a status that someone responded to it.
+type StatusNotification struct {
+	Type              StatusNotificationType `json:",omitempty"` // use StatusNotificationType* constants
+	StatusID          *string                `json:",omitempty"` // the ID of our status that was responded to, not always set
+	ResponseMessageID *string                `json:",omitempty"` // the ID of the status that was posted in response, not always set
+}
+
+type StatusNotificationType string
+
+const (
+	StatusNotificationTypeAddYours              StatusNotificationType = "add-yours"
+	StatusNotificationTypeReshare               StatusNotificationType = "reshare"
+	StatusNotificationTypeQuestionAnswerReshare StatusNotificationType = "question-answer-reshare"
+)
+
+// isStatus reports whether the message is a status update, rather than a message in a chat.
+func isStatus(m events.Message) bool {
+	return m.Info.Chat == types.StatusBroadcastJID || m.Message.GetGroupStatusMessage().GetMessage() != nil
+}
+
+func (conn *Connection) handleStatus(m events.Message) {
+	if conn.Callbacks.Status == nil || !hasStatusContent(m.Message) {
+		return
+	}
+	conn.Callbacks.Status(conn.parseStatus(m))
+}
+
+// hasStatusContent reports whether the message has text or media to show as a status.
+// Revokes, reactions and key distribution messages are sent to status@broadcast too, but aren't statuses.
+func hasStatusContent(m *waE2E.Message) bool {
+	if x := m.GetGroupStatusMessage().GetMessage(); x != nil {
+		m = x
+	}
+	if x := m.GetStatusAddYours().GetMessage(); x != nil {
+		m = x
+	}
+	return m.GetConversation() != "" ||
+		m.GetExtendedTextMessage().GetText() != "" ||
+		m.GetImageMessage() != nil ||
+		m.GetVideoMessage() != nil ||
+		m.GetAudioMessage() != nil ||
+		m.GetPtvMessage() != nil
+}
+
+func (conn *Connection) parseStatus(m events.Message) Status {
+	status := Status{
+		StatusID:  m.Info.ID,
+		PosterJID: m.Info.Sender.ToNonAD().String(),
+		IsFromMe:  &m.Info.IsFromMe,
+		Raw:       m,
+	}
+	if m.Message.GetGroupStatusMessage().GetMessage() != nil {
+		status.GroupJID = proto.String(m.Info.Chat.String())
+	}
+	if m.Message.GetStatusAddYours().GetMessage() != nil {
+		status.IsAddYours = proto.Bool(true)
+	}
+	// the wrappers are unwrapped by parseEventMessage, which also downloads the media
+	message := conn.parseEventMessage(m)
+	if !m.Info.Timestamp.IsZero() {
+		expiry := m.Info.Timestamp.Add(statusLifetime)
+		status.Timestamp = &m.Info.Timestamp
+		status.Expiry = &expiry
+	}
+	if message.ContentBody != nil && *message.ContentBody != "" {
+		status.Caption = message.ContentBody
+	}
+	status.Attachments = message.Attachments
+	status.TextFont = message.TextFont
+	status.TextColorARGB = message.TextColorARGB
+	status.TextBackgroundARGB = message.TextBackgroundARGB
+	return status
+}
+
+// parseStatusMention parses the copy of a status that is sent to the chat or group it mentions.
+func (conn *Connection) parseStatusMention(m events.Message, mentioned *waE2E.Message) Status {
+	m.Message = mentioned
+	status := conn.parseStatus(m)
+	// the ID is that of the mention, and the mention doesn't carry the key of the original status
+	status.StatusID = ""
+	status.Raw = nil
+	return status
+}
+
+func parseStatusNotificationMessage(x *waE2E.StatusNotificationMessage) StatusNotification {
+	notification := StatusNotification{
+		StatusID:          nonEmpty(x.GetOriginalMessageKey().GetID()),
+		ResponseMessageID: nonEmpty(x.GetResponseMessageKey().GetID()),
+	}
+	switch x.GetType() {
+	case waE2E.StatusNotificationMessage_STATUS_ADD_YOURS:
+		notification.Type = StatusNotificationTypeAddYours
+	case waE2E.StatusNotificationMessage_STATUS_RESHARE:
+		notification.Type = StatusNotificationTypeReshare
+	case waE2E.StatusNotificationMessage_STATUS_QUESTION_ANSWER_RESHARE:
+		notification.Type = StatusNotificationTypeQuestionAnswerReshare
+	}
+	return notification
+}
+
+// PostStatus posts a text or media status. whatsmeow always sends it to the recipients allowed by the account's
+// status privacy setting, so when recipients are given they must match the "Only share with..." list set in the
+// app, otherwise an error is returned and nothing is posted.
+func (conn *Connection) PostStatus(status Status, recipients []string) (Status, error) {
+	var (
+		out *waE2E.Message
+		err error
+	)
+	if len(recipients) > 0 {
+		jids, err := parseParticipantJIDs(recipients)
+		if err != nil {
+			return status, err
+		}
+		privacy, err := conn.client.GetStatusPrivacy()
+		if err != nil {
+			return status, fmt.Errorf("failed to get status privacy: %w", err)
+		}
+		if !isStatusShareList(privacy, jids) {
+			return status, errors.New("status recipients don't match the \"Only share with...\" list in the status privacy settings")
+		}
+	}
+	if len(status.Attachments) > 0 {
+		out, _, err = conn.buildAttachmentMessage(types.StatusBroadcastJID, Message{
+			ContentBody: status.Caption,
+			Attachments: status.Attachments,
+		})
+	} else {
+		out, err = buildTextStatusMessage(status)
+	}
+	if err != nil {
+		return status, err
+	}
+	resp, err := conn.client.SendMessage(context.Background(), types.StatusBroadcastJID, out)
+	if err != nil {
+		return status, fmt.Errorf("failed to post status: %w", err)
+	}
+	expiry := resp.Timestamp.Add(statusLifetime)
+	status.StatusID = resp.ID
+	status.PosterJID = resp.Sender.ToNonAD().String()
+	status.IsFromMe = proto.Bool(true)
+	status.Timestamp = &resp.Timestamp
+	status.Expiry = &expiry
+	return status, nil
+}
+
+// isStatusShareList reports whether statuses are only shared with exactly the given recipients.
+func isStatusShareList(privacy []types.StatusPrivacy, recipients []types.JID) bool {
+	if len(privacy) == 0 || privacy[0].Type != types.StatusPrivacyTypeWhitelist {
+		return false
+	}
+	list := make(map[types.JID]bool, len(privacy[0].List))
+	for _, jid := range privacy[0].List {
+		list[jid.ToNonAD()] = true
+	}
+	wanted := make(map[types.JID]bool, len(recipients))
+	for _, jid := range recipients {
+		if !list[jid.ToNonAD()] {
+			return false
+		}
+		wanted[jid.ToNonAD()] = true
+	}
+	return len(wanted) == len(list)
+}
+
+func buildTextStatusMessage(status Status) (*waE2E.Message, error) {
+	if status.Caption == nil || *status.Caption == "" {
+		return nil, errors.New("missing status.Caption or status.Attachments")
+	}
+	text := &waE2E.ExtendedTextMessage{
+		Text:           status.Caption,
+		TextArgb:       status.TextColorARGB,
+		BackgroundArgb: status.TextBackgroundARGB,
+	}
+	if status.TextFont != nil {
+		font, ok := waE2E.ExtendedTextMessage_FontType_value[*status.TextFont]
+		if !ok {
+			return nil, fmt.Errorf("unknown status.TextFont: %s", *status.TextFont)
+		}
+		text.Font = waE2E.ExtendedTextMessage_FontType(font).Enum()
+	}
+	return &waE2E.Message{ExtendedTextMessage: text}, nil
+}
@@ -0,0 +1,193 @@
+package whatsmgr
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseStatus(t *testing.T) {
+	posted := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	poster := types.NewJID("123456789", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	text := &waE2E.Message{
+		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:           proto.String("Open all weekend"),
+			BackgroundArgb: proto.Uint32(0xff7acba5),
+		},
+	}
+
+	tests := []struct {
+		note       string
+		chat       types.JID
+		message    *waE2E.Message
+		isStatus   bool
+		groupJID   *string
+		isAddYours *bool
+	}{
+		{
+			note:     "text status",
+			chat:     types.StatusBroadcastJID,
+			message:  text,
+			isStatus: true,
+		},
+		{
+			note:     "group status",
+			chat:     group,
+			message:  &waE2E.Message{GroupStatusMessage: &waE2E.FutureProofMessage{Message: text}},
+			isStatus: true,
+			groupJID: proto.String(group.String()),
+		},
+		{
+			note:       "add yours status",
+			chat:       types.StatusBroadcastJID,
+			message:    &waE2E.Message{StatusAddYours: &waE2E.FutureProofMessage{Message: text}},
+			isStatus:   true,
+			isAddYours: proto.Bool(true),
+		},
+		{
+			note:     "chat message",
+			chat:     poster,
+			message:  text,
+			isStatus: false,
+		},
+	}
+	for _, test := range tests {
+		m := events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: test.chat, Sender: poster},
+				ID:            "3EB0C0FFEE0000000005",
+				Timestamp:     posted,
+			},
+			Message: test.message,
+		}
+		if isStatus(m) != test.isStatus {
+			t.Errorf("%s: Expected isStatus %v, got %v", test.note, test.isStatus, !test.isStatus)
+		}
+		if !test.isStatus {
+			continue
+		}
+		status := (&Connection{}).parseStatus(m)
+		if status.PosterJID != poster.String() || status.StatusID != "3EB0C0FFEE0000000005" {
+			t.Errorf("%s: Unexpected poster or ID: %s %s", test.note, status.PosterJID, status.StatusID)
+		}
+		if stringOrEmpty(status.Caption) != "Open all weekend" {
+			t.Errorf("%s: Expected caption \"Open all weekend\", got %q", test.note, stringOrEmpty(status.Caption))
+		}
+		if status.TextBackgroundARGB == nil || *status.TextBackgroundARGB != 0xff7acba5 {
+			t.Errorf("%s: Expected background 0xff7acba5, got %v", test.note, status.TextBackgroundARGB)
+		}
+		if status.Expiry == nil || !status.Expiry.Equal(posted.Add(24*time.Hour)) {
+			t.Errorf("%s: Expected expiry %s, got %v", test.note, posted.Add(24*time.Hour), status.Expiry)
+		}
+		if stringOrEmpty(status.GroupJID) != stringOrEmpty(test.groupJID) {
+			t.Errorf("%s: Expected group %q, got %q", test.note, stringOrEmpty(test.groupJID), stringOrEmpty(status.GroupJID))
+		}
+		if (status.IsAddYours != nil) != (test.isAddYours != nil) {
+			t.Errorf("%s: Expected IsAddYours %v, got %v", test.note, test.isAddYours, status.IsAddYours)
+		}
+	}
+}
+
+func TestHasStatusContent(t *testing.T) {
+	text := &waE2E.Message{Conversation: proto.String("Open all weekend")}
+	tests := []struct {
+		note     string
+		message  *waE2E.Message
+		expected bool
+	}{
+		{note: "text", message: text, expected: true},
+		{note: "image", message: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{}}, expected: true},
+		{note: "group status", message: &waE2E.Message{GroupStatusMessage: &waE2E.FutureProofMessage{Message: text}}, expected: true},
+		{note: "revoke", message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{Type: waE2E.ProtocolMessage_REVOKE.Enum()}}, expected: false},
+		{note: "reaction", message: &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: proto.String("👍")}}, expected: false},
+		{note: "key distribution", message: &waE2E.Message{SenderKeyDistributionMessage: &waE2E.SenderKeyDistributionMessage{}}, expected: false},
+		{note: "empty", message: nil, expected: false},
+	}
+	for _, test := range tests {
+		if actual := hasStatusContent(test.message); actual != test.expected {
+			t.Errorf("%s: Expected %v, got %v", test.note, test.expected, actual)
+		}
+	}
+}
+
+func TestIsStatusShareList(t *testing.T) {
+	first := types.NewJID("27820000001", types.DefaultUserServer)
+	second := types.NewJID("27820000002", types.DefaultUserServer)
+	tests := []struct {
+		note       string
+		privacy    []types.StatusPrivacy
+		recipients []types.JID
+		expected   bool
+	}{
+		{
+			note:       "same list in another order",
+			privacy:    []types.StatusPrivacy{{Type: types.StatusPrivacyTypeWhitelist, List: []types.JID{first, second}}},
+			recipients: []types.JID{second, first},
+			expected:   true,
+		},
+		{
+			note:       "missing recipient",
+			privacy:    []types.StatusPrivacy{{Type: types.StatusPrivacyTypeWhitelist, List: []types.JID{first}}},
+			recipients: []types.JID{first, second},
+			expected:   false,
+		},
+		{
+			note:       "duplicate recipient",
+			privacy:    []types.StatusPrivacy{{Type: types.StatusPrivacyTypeWhitelist, List: []types.JID{first, second}}},
+			recipients: []types.JID{first, first},
+			expected:   false,
+		},
+		{
+			note:       "duplicate of the only recipient",
+			privacy:    []types.StatusPrivacy{{Type: types.StatusPrivacyTypeWhitelist, List: []types.JID{first}}},
+			recipients: []types.JID{first, first},
+			expected:   true,
+		},
+		{
+			note:       "all contacts",
+			privacy:    []types.StatusPrivacy{{Type: types.StatusPrivacyTypeContacts}},
+			recipients: []types.JID{first},
+			expected:   false,
+		},
+		{
+			note:       "contacts except",
+			privacy:    []types.StatusPrivacy{{Type: types.StatusPrivacyTypeBlacklist, List: []types.JID{first}}},
+			recipients: []types.JID{first},
+			expected:   false,
+		},
+	}
+	for _, test := range tests {
+		if actual := isStatusShareList(test.privacy, test.recipients); actual != test.expected {
+			t.Errorf("%s: Expected %v, got %v", test.note, test.expected, actual)
+		}
+	}
+}
+
+func TestBuildTextStatusMessage(t *testing.T) {
+	out, err := buildTextStatusMessage(Status{
+		Caption:            proto.String("Open all weekend"),
+		TextFont:           proto.String("CALISTOGA_REGULAR"),
+		TextBackgroundARGB: proto.Uint32(0xff7acba5),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if out.GetExtendedTextMessage().GetFont() != waE2E.ExtendedTextMessage_CALISTOGA_REGULAR {
+		t.Errorf("Expected font CALISTOGA_REGULAR, got %s", out.GetExtendedTextMessage().GetFont())
+	}
+	if out.GetExtendedTextMessage().GetBackgroundArgb() != 0xff7acba5 {
+		t.Errorf("Expected background 0xff7acba5, got %x", out.GetExtendedTextMessage().GetBackgroundArgb())
+	}
+
+	if _, err := buildTextStatusMessage(Status{Caption: proto.String("Hi"), TextFont: proto.String("COMIC_SANS")}); err == nil {
+		t.Errorf("Expected an error for an unknown font")
+	}
+	if _, err := buildTextStatusMessage(Status{}); err == nil {
+		t.Errorf("Expected an error for an empty status")
+	}
+}
@@ -0,0 +1,308 @@
+package whatsmgr
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// StructuredMessage is a message with buttons or a list, as sent by business accounts and bots.
+type StructuredMessage struct {
+	Type       StructuredMessageType `json:",omitempty"` // use StructuredMessageType* constants
+	TemplateID *string               `json:",omitempty"` // not always set
+
+	Header *string `json:",omitempty"` // not always set
+	Body   *string `json:",omitempty"` // not always set
+	Footer *string `json:",omitempty"` // not always set
+
+	Buttons    []StructuredButton  `json:",omitempty"` // not always set
+	ListButton *string             `json:",omitempty"` // the text of the button that opens the list, not always set
+	Sections   []StructuredSection `json:",omitempty"` // not always set
+}
+
+type StructuredButton struct {
+	ID          *string `json:",omitempty"` // sent back in Selection.SelectedID when tapped, not always set
+	DisplayText *string `json:",omitempty"` // not always set
+	URL         *string `json:",omitempty"` // for buttons that open a link, not always set
+	PhoneNumber *string `json:",omitempty"` // for buttons that start a call, not always set
+}
+
+type StructuredSection struct {
+	Title *string         `json:",omitempty"` // not always set
+	Rows  []StructuredRow `json:",omitempty"`
+}
+
+type StructuredRow struct {
+	ID          *string `json:",omitempty"` // sent back in Selection.SelectedID when tapped, not always set
+	Title       *string `json:",omitempty"` // not always set
+	Description *string `json:",omitempty"` // not always set
+}
+
+type StructuredMessageType string
+
+const (
+	StructuredMessageTypeButtons          StructuredMessageType = "buttons"
+	StructuredMessageTypeList             StructuredMessageType = "list"
+	StructuredMessageTypeTemplate         StructuredMessageType = "template"
+	StructuredMessageTypeHighlyStructured StructuredMessageType = "highly-structured"
+	StructuredMessageTypeInteractive      StructuredMessageType = "interactive"
+)
+
+// Text renders the message as plain text, for clients that can't show buttons or lists.
+func (s StructuredMessage) Text() string {
+	var parts []string
+	for _, text := range []*string{s.Header, s.Body, s.Footer} {
+		if text != nil && *text != "" {
+			parts = append(parts, *text)
+		}
+	}
+	var buttons []string
+	for _, button := range s.Buttons {
+		line := "- " + stringOrEmpty(button.DisplayText)
+		if button.URL != nil {
+			line += " (" + *button.URL + ")"
+		} else if button.PhoneNumber != nil {
+			line += " (" + *button.PhoneNumber + ")"
+		}
+		buttons = append(buttons, line)
+	}
+	if len(buttons) > 0 {
+		parts = append(parts, strings.Join(buttons, "\n"))
+	}
+	for _, section := range s.Sections {
+		var lines []string
+		if section.Title != nil && *section.Title != "" {
+			lines = append(lines, *section.Title+":")
+		}
+		for _, row := range section.Rows {
+			line := "- " + stringOrEmpty(row.Title)
+			if row.Description != nil && *row.Description != "" {
+				line += ": " + *row.Description
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func parseButtonsMessage(x *waE2E.ButtonsMessage) StructuredMessage {
+	structured := StructuredMessage{
+		Type:   StructuredMessageTypeButtons,
+		Body:   x.ContentText,
+		Footer: x.FooterText,
+	}
+	if header := x.GetText(); header != "" {
+		structured.Header = &header
+	}
+	for _, button := range x.Buttons {
+		if button == nil {
+			continue
+		}
+		if flow := button.NativeFlowInfo; flow != nil {
+			structured.Buttons = append(structured.Buttons, parseNativeFlowButton(flow.GetParamsJSON()))
+			continue
+		}
+		structured.Buttons = append(structured.Buttons, StructuredButton{
+			ID:          button.ButtonID,
+			DisplayText: nonEmpty(button.GetButtonText().GetDisplayText()),
+		})
+	}
+	return structured
+}
+
+func parseListMessage(x *waE2E.ListMessage) StructuredMessage {
+	structured := StructuredMessage{
+		Type:       StructuredMessageTypeList,
+		Header:     x.Title,
+		Body:       x.Description,
+		Footer:     x.FooterText,
+		ListButton: x.ButtonText,
+	}
+	for _, section := range x.Sections {
+		if section == nil {
+			continue
+		}
+		structuredSection := StructuredSection{Title: section.Title}
+		for _, row := range section.Rows {
+			if row == nil {
+				continue
+			}
+			structuredSection.Rows = append(structuredSection.Rows, StructuredRow{
+				ID:          row.RowID,
+				Title:       row.Title,
+				Description: row.Description,
+			})
+		}
+		structured.Sections = append(structured.Sections, structuredSection)
+	}
+	return structured
+}
+
+func parseTemplateMessage(x *waE2E.TemplateMessage) StructuredMessage {
+	if interactive := x.GetInteractiveMessageTemplate(); interactive != nil {
+		structured := parseInteractiveMessage(interactive)
+		structured.Type = StructuredMessageTypeTemplate
+		structured.TemplateID = x.TemplateID
+		return structured
+	}
+	structured := StructuredMessage{
+		Type:       StructuredMessageTypeTemplate,
+		TemplateID: x.TemplateID,
+	}
+	template := x.GetHydratedTemplate()
+	if template == nil {
+		template = x.GetHydratedFourRowTemplate()
+	}
+	if template == nil {
+		return structured
+	}
+	if structured.TemplateID == nil {
+		structured.TemplateID = template.TemplateID
+	}
+	if title := template.GetHydratedTitleText(); title != "" {
+		structured.Header = &title
+	}
+	structured.Body = template.HydratedContentText
+	structured.Footer = template.HydratedFooterText
+	for _, button := range template.HydratedButtons {
+		if button == nil {
+			continue
+		}
+		switch {
+		case button.GetQuickReplyButton() != nil:
+			structured.Buttons = append(structured.Buttons, StructuredButton{
+				ID:          button.GetQuickReplyButton().ID,
+				DisplayText: button.GetQuickReplyButton().DisplayText,
+			})
+		case button.GetUrlButton() != nil:
+			structured.Buttons = append(structured.Buttons, StructuredButton{
+				DisplayText: button.GetUrlButton().DisplayText,
+				URL:         button.GetUrlButton().URL,
+			})
+		case button.GetCallButton() != nil:
+			structured.Buttons = append(structured.Buttons, StructuredButton{
+				DisplayText: button.GetCallButton().DisplayText,
+				PhoneNumber: button.GetCallButton().PhoneNumber,
+			})
+		}
+	}
+	return structured
+}
+
+func parseHighlyStructuredMessage(x *waE2E.HighlyStructuredMessage) StructuredMessage {
+	if x.HydratedHsm != nil {
+		structured := parseTemplateMessage(x.HydratedHsm)
+		structured.Type = StructuredMessageTypeHighlyStructured
+		if structured.TemplateID == nil {
+			structured.TemplateID = x.ElementName
+		}
+		return structured
+	}
+	// without the hydrated template all we have are the template name and its parameters
+	structured := StructuredMessage{
+		Type:       StructuredMessageTypeHighlyStructured,
+		TemplateID: x.ElementName,
+	}
+	if len(x.Params) > 0 {
+		body := strings.Join(x.Params, "\n")
+		structured.Body = &body
+	}
+	return structured
+}
+
+func parseInteractiveMessage(x *waE2E.InteractiveMessage) StructuredMessage {
+	structured := StructuredMessage{
+		Type:   StructuredMessageTypeInteractive,
+		Body:   nonEmpty(x.GetBody().GetText()),
+		Footer: nonEmpty(x.GetFooter().GetText()),
+	}
+	if header := x.GetHeader(); header != nil {
+		title := strings.TrimSpace(header.GetTitle() + "\n" + header.GetSubtitle())
+		if title != "" {
+			structured.Header = &title
+		}
+	}
+	for _, button := range x.GetNativeFlowMessage().GetButtons() {
+		if button == nil {
+			continue
+		}
+		if button.GetName() == "single_select" {
+			listButton, sections := parseNativeFlowList(button.GetButtonParamsJSON())
+			structured.ListButton = listButton
+			structured.Sections = append(structured.Sections, sections...)
+			continue
+		}
+		structured.Buttons = append(structured.Buttons, parseNativeFlowButton(button.GetButtonParamsJSON()))
+	}
+	return structured
+}
+
+// parseNativeFlowButton reads the button params of a native flow button, like quick_reply, cta_url and cta_call.
+func parseNativeFlowButton(paramsJSON string) (button StructuredButton) {
+	var params struct {
+		DisplayText string `json:"display_text"`
+		ID          string `json:"id"`
+		URL         string `json:"url"`
+		PhoneNumber string `json:"phone_number"`
+	}
+	if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		return
+	}
+	for _, field := range []struct {
+		value  string
+		target **string
+	}{
+		{params.DisplayText, &button.DisplayText},
+		{params.ID, &button.ID},
+		{params.URL, &button.URL},
+		{params.PhoneNumber, &button.PhoneNumber},
+	} {
+		if field.value != "" {
+			value := field.value
+			*field.target = &value
+		}
+	}
+	return
+}
+
+// parseNativeFlowList reads the sections of a single_select native flow button.
+func parseNativeFlowList(paramsJSON string) (listButton *string, sections []StructuredSection) {
+	var params struct {
+		Title    string `json:"title"`
+		Sections []struct {
+			Title string `json:"title"`
+			Rows  []struct {
+				ID          string `json:"id"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+			} `json:"rows"`
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		return
+	}
+	listButton = nonEmpty(params.Title)
+	for _, section := range params.Sections {
+		structuredSection := StructuredSection{Title: nonEmpty(section.Title)}
+		for _, row := range section.Rows {
+			structuredSection.Rows = append(structuredSection.Rows, StructuredRow{
+				ID:          nonEmpty(row.ID),
+				Title:       nonEmpty(row.Title),
+				Description: nonEmpty(row.Description),
+			})
+		}
+		sections = append(sections, structuredSection)
+	}
+	return
+}
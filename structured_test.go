@@ -0,0 +1,52 @@
+package whatsmgr
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseInteractiveMessage(t *testing.T) {
+	x := &waE2E.InteractiveMessage{
+		Header: &waE2E.InteractiveMessage_Header{Title: proto.String("Order #123")},
+		Body:   &waE2E.InteractiveMessage_Body{Text: proto.String("Your order is ready")},
+		InteractiveMessage: &waE2E.InteractiveMessage_NativeFlowMessage_{
+			NativeFlowMessage: &waE2E.InteractiveMessage_NativeFlowMessage{
+				Buttons: []*waE2E.InteractiveMessage_NativeFlowMessage_NativeFlowButton{
+					{Name: proto.String("quick_reply"), ButtonParamsJSON: proto.String(`{"display_text":"Collect","id":"collect"}`)},
+					{Name: proto.String("cta_url"), ButtonParamsJSON: proto.String(`{"display_text":"Track","url":"https://example.com/track"}`)},
+					{Name: proto.String("single_select"), ButtonParamsJSON: proto.String(`{"title":"Other options","sections":[{"title":"Delivery","rows":[{"id":"deliver","title":"Deliver it","description":"R50"}]}]}`)},
+				},
+			},
+		},
+	}
+	structured := parseInteractiveMessage(x)
+	if len(structured.Buttons) != 2 || stringOrEmpty(structured.Buttons[0].ID) != "collect" || stringOrEmpty(structured.Buttons[1].URL) != "https://example.com/track" {
+		t.Errorf("Unexpected buttons: %+v", structured.Buttons)
+	}
+	if len(structured.Sections) != 1 || len(structured.Sections[0].Rows) != 1 || *structured.Sections[0].Rows[0].ID != "deliver" {
+		t.Errorf("Unexpected sections: %+v", structured.Sections)
+	}
+	expected := "Order #123\n\nYour order is ready\n\n- Collect\n- Track (https://example.com/track)\n\nDelivery:\n- Deliver it: R50"
+	if text := structured.Text(); text != expected {
+		t.Errorf("Expected text:\n%s\n\nGot:\n%s", expected, text)
+	}
+}
+
+func TestParseNativeFlowList(t *testing.T) {
+	listButton, sections := parseNativeFlowList(`{"sections":[{"rows":[{"id":"collect","title":"Collect it"}]}]}`)
+	if listButton != nil {
+		t.Errorf("Expected no list button, got %q", *listButton)
+	}
+	if len(sections) != 1 || len(sections[0].Rows) != 1 {
+		t.Fatalf("Unexpected sections: %+v", sections)
+	}
+	if sections[0].Title != nil {
+		t.Errorf("Expected no section title, got %q", *sections[0].Title)
+	}
+	row := sections[0].Rows[0]
+	if stringOrEmpty(row.ID) != "collect" || stringOrEmpty(row.Title) != "Collect it" || row.Description != nil {
+		t.Errorf("Unexpected row: %+v", row)
+	}
+}
@@ -0,0 +1,159 @@
+package whatsmgr
+
+import (
+	"errors"
+	"strings"
+)
+
+// VCard is the subset of a vCard that WhatsApp uses when sharing contacts.
+type VCard struct {
+	FullName     string
+	FirstName    string
+	LastName     string
+	Organization string
+	Phones       []VCardPhone
+	Emails       []string
+}
+
+type VCardPhone struct {
+	Number string
+	Type   string // CELL, HOME, WORK etc. defaults to CELL when building
+	WAID   string // the WhatsApp user (phone number without the +) if the number is on WhatsApp, not always set
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+var vcardUnescaper = strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n")
+
+// ParseVCard parses the first vCard in raw.
+func ParseVCard(raw string) (card VCard, err error) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	// long lines are folded by starting the continuation with a space or tab
+	raw = strings.ReplaceAll(raw, "\n ", "")
+	raw = strings.ReplaceAll(raw, "\n\t", "")
+
+	started := false
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		params := strings.Split(key, ";")
+		name := strings.ToUpper(params[0])
+		// grouped properties look like item1.TEL
+		if _, after, grouped := strings.Cut(name, "."); grouped {
+			name = after
+		}
+		params = params[1:]
+
+		switch name {
+		case "BEGIN":
+			started = strings.EqualFold(value, "VCARD")
+			continue
+		case "END":
+			if started {
+				return card, nil
+			}
+		}
+		if !started {
+			continue
+		}
+		switch name {
+		case "FN":
+			card.FullName = vcardUnescaper.Replace(value)
+		case "N":
+			parts := vcardSplit(value)
+			card.LastName = parts[0]
+			if len(parts) > 1 {
+				card.FirstName = parts[1]
+			}
+		case "ORG":
+			card.Organization = strings.TrimSpace(strings.Join(vcardSplit(value), " "))
+		case "TEL":
+			phone := VCardPhone{Number: vcardUnescaper.Replace(value)}
+			for _, param := range params {
+				paramName, paramValue, _ := strings.Cut(param, "=")
+				switch strings.ToLower(paramName) {
+				case "type":
+					// WhatsApp repeats type for every value, the first one is the most specific
+					if phone.Type == "" {
+						phone.Type = strings.ToUpper(paramValue)
+					}
+				case "waid":
+					phone.WAID = paramValue
+				}
+			}
+			card.Phones = append(card.Phones, phone)
+		case "EMAIL":
+			card.Emails = append(card.Emails, vcardUnescaper.Replace(value))
+		}
+	}
+	if !started {
+		return card, errors.New("missing BEGIN:VCARD")
+	}
+	return card, errors.New("missing END:VCARD")
+}
+
+// vcardSplit splits a structured value on unescaped semicolons.
+func vcardSplit(value string) (parts []string) {
+	var current strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteString(vcardUnescaper.Replace(`\` + string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, current.String())
+}
+
+// DisplayName returns the name WhatsApp should show for the contact.
+func (card VCard) DisplayName() string {
+	if card.FullName != "" {
+		return card.FullName
+	}
+	if name := strings.TrimSpace(card.FirstName + " " + card.LastName); name != "" {
+		return name
+	}
+	if card.Organization != "" {
+		return card.Organization
+	}
+	if len(card.Phones) > 0 {
+		return card.Phones[0].Number
+	}
+	return ""
+}
+
+// String builds a vCard 3.0 in the format WhatsApp sends.
+func (card VCard) String() string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\nVERSION:3.0\n")
+	b.WriteString("N:" + vcardEscaper.Replace(card.LastName) + ";" + vcardEscaper.Replace(card.FirstName) + ";;;\n")
+	b.WriteString("FN:" + vcardEscaper.Replace(card.DisplayName()) + "\n")
+	if card.Organization != "" {
+		b.WriteString("ORG:" + vcardEscaper.Replace(card.Organization) + ";\n")
+	}
+	for _, phone := range card.Phones {
+		phoneType := phone.Type
+		if phoneType == "" {
+			phoneType = "CELL"
+		}
+		b.WriteString("TEL;type=" + phoneType + ";type=VOICE")
+		if phone.WAID != "" {
+			b.WriteString(";waid=" + phone.WAID)
+		}
+		b.WriteString(":" + phone.Number + "\n")
+	}
+	for _, email := range card.Emails {
+		b.WriteString("EMAIL:" + vcardEscaper.Replace(email) + "\n")
+	}
+	b.WriteString("END:VCARD")
+	return b.String()
+}
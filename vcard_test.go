@@ -0,0 +1,51 @@
+package whatsmgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVCard(t *testing.T) {
+	raw := "BEGIN:VCARD\r\nVERSION:3.0\r\nN:Blow;Joe;;;\r\nFN:Joe Blow\r\nORG:Acme\\, Inc.;\r\nitem1.TEL;type=CELL;type=VOICE;waid=27821234567:+27 82 123 4567\r\nitem1.X-ABLabel:Mobile\r\nEMAIL;type=INTERNET:joe@exam\r\n ple.com\r\nEND:VCARD"
+	expected := VCard{
+		FullName:     "Joe Blow",
+		FirstName:    "Joe",
+		LastName:     "Blow",
+		Organization: "Acme, Inc.",
+		Phones:       []VCardPhone{{Number: "+27 82 123 4567", Type: "CELL", WAID: "27821234567"}},
+		Emails:       []string{"joe@example.com"},
+	}
+	card, err := ParseVCard(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(card, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, card)
+	}
+
+	if _, err := ParseVCard("FN:Joe Blow"); err == nil {
+		t.Errorf("Expected error for missing BEGIN:VCARD")
+	}
+}
+
+func TestVCardString(t *testing.T) {
+	card := VCard{
+		FirstName: "Joe",
+		LastName:  "Blow",
+		Phones:    []VCardPhone{{Number: "+27 82 123 4567", WAID: "27821234567"}},
+	}
+	expected := "BEGIN:VCARD\nVERSION:3.0\nN:Blow;Joe;;;\nFN:Joe Blow\nTEL;type=CELL;type=VOICE;waid=27821234567:+27 82 123 4567\nEND:VCARD"
+	if got := card.String(); got != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, got)
+	}
+
+	parsed, err := ParseVCard(card.String())
+	if err != nil {
+		t.Fatalf("Unexpected error parsing built vCard: %v", err)
+	}
+	card.FullName = "Joe Blow"
+	card.Phones[0].Type = "CELL"
+	if !reflect.DeepEqual(parsed, card) {
+		t.Errorf("Round trip mismatch, expected %+v, got %+v", card, parsed)
+	}
+}